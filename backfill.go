@@ -0,0 +1,86 @@
+package gwyneth
+
+import (
+	"fmt"
+	"time"
+)
+
+import (
+	"github.com/l4go/task"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+
+	"github.com/hinoshiba/gwyneth/collector"
+)
+
+const (
+	BACKFILL_DEFAULT_PAGES = 10
+	BACKFILL_MAX_PAGES     = 100
+)
+
+// BackfillSource reads the older pages of the source up to limit pages in background, and returns
+// the job which can be read by GetFetchJob. The articles keep their dates, and they are passed to
+// the filters only with actions, so the actions are not fired for the old articles by default.
+func (self *Gwyneth) BackfillSource(id *model.Id, limit int, actions bool) (*model.FetchJob, error) {
+	src, err := self.tv.GetSource(id)
+	if err != nil {
+		return nil, err
+	}
+	if src.IsPause() {
+		return nil, fmt.Errorf("the source is paused.")
+	}
+	if limit < 1 {
+		limit = BACKFILL_DEFAULT_PAGES
+	}
+	if limit > BACKFILL_MAX_PAGES {
+		return nil, fmt.Errorf("the limit is over %d pages.", BACKFILL_MAX_PAGES)
+	}
+
+	clctr, err := collector.Lookup(src.Type())
+	if err != nil {
+		return nil, err
+	}
+	b, ok := clctr.(collector.Backfiller)
+	if !ok {
+		return nil, fmt.Errorf("the type '%s' does not support the backfill.", src.Type().Name())
+	}
+
+	opt, err := self.tv.GetSourceOption(id)
+	if err != nil {
+		return nil, err
+	}
+	client, err := self.newHttpClient(opt.Http)
+	if err != nil {
+		return nil, err
+	}
+
+	f_job := self.fetch_mgr.Begin(id, time.Now())
+	job := &collector.Job{
+		Logger: self.lm.GetCollectorsLogger(),
+		Src: src,
+		Http: client,
+	}
+	go self.backfill(self.msn.New(), f_job, b, job, limit, actions)
+	return f_job, nil
+}
+
+func (self *Gwyneth) backfill(msn *task.Mission, f_job *model.FetchJob, b collector.Backfiller, job *collector.Job, limit int, actions bool) {
+	defer msn.Done()
+
+	job.Logger.Debug("the backfill of '%s' is running... :'%s'", job.Src.Title(), job.Src.Value())
+	var st *model.Status
+	new_artcls, err := self.runJob(msn.New(), job, !actions, func() {
+		st = b.Backfill(msn.New(), job, limit)
+	})
+	if err != nil && st.IsSuccess {
+		st = collector.MakeFailedStatus("%s", err)
+	}
+	if !st.IsSuccess {
+		job.Logger.Warn("cannot backfill '%s/%s': %s", job.Src.Title(), job.Src.Value(), st.Log)
+	}
+
+	self.fetch_mgr.End(f_job.Id, st)
+	self.addFetchLog(job.Src.Id(), f_job.Started, st, job, new_artcls)
+}
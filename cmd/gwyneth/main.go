@@ -16,7 +16,15 @@ import (
 	"github.com/hinoshiba/gwyneth"
 	"github.com/hinoshiba/gwyneth/slog"
 	"github.com/hinoshiba/gwyneth/http"
+	"github.com/hinoshiba/gwyneth/smtp"
 	"github.com/hinoshiba/gwyneth/config"
+
+	_ "github.com/hinoshiba/gwyneth/collector/rss"
+	_ "github.com/hinoshiba/gwyneth/collector/scrape"
+	_ "github.com/hinoshiba/gwyneth/collector/jsonapi"
+	_ "github.com/hinoshiba/gwyneth/collector/mail"
+	_ "github.com/hinoshiba/gwyneth/collector/directory"
+	_ "github.com/hinoshiba/gwyneth/collector/extension"
 )
 
 var (
@@ -49,6 +57,14 @@ func gwyneth_cmd() error {
 	}
 	defer rt.Close()
 
+	if Config.Smtp != nil {
+		sv, err := smtp.New(msn.New(), Config.Smtp, g)
+		if err != nil {
+			return err
+		}
+		defer sv.Close()
+	}
+
 	slog.Info("gwyneth started")
 	msn.Done()
 
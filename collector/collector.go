@@ -0,0 +1,285 @@
+package collector
+
+import (
+	"io"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"sync/atomic"
+)
+
+import (
+	"github.com/l4go/task"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/slog"
+	"github.com/hinoshiba/gwyneth/model"
+)
+
+var (
+	registry = newIndex()
+
+	extension Collector
+	extension_mtx = new(sync.RWMutex)
+
+	command_timeout atomic.Int64
+)
+
+type Collector interface {
+	Collect(*task.Mission, *Job) *model.Status
+}
+
+// Watcher is a collector which can watch the source. notice is called when the source should be collected.
+type Watcher interface {
+	Watch(msn *task.Mission, src *model.Source, notice func()) error
+}
+
+// Receiver is a collector which can also read the content pushed by the source.
+type Receiver interface {
+	Receive(*task.Mission, *Job, io.Reader) error
+}
+
+// Backfiller is a collector which can also read the older pages of the source, up to limit pages.
+type Backfiller interface {
+	Backfill(msn *task.Mission, job *Job, limit int) *model.Status
+}
+
+type Job struct {
+	Logger    *slog.Logger
+	Src       *model.Source
+	State     *model.SourceState
+	ArticleCh chan <- *model.Article
+	Http      *HttpClient // the settings of the source. the default client is used if it is nil.
+
+	// DryRun is set by a preview. The collector must not change the source, e.g. moving files.
+	DryRun    bool
+
+	latest      int64
+	items       int
+	dropped     int
+	http_status int
+	bytes       int64
+	mtx         sync.Mutex
+
+	sending sync.WaitGroup
+	commits []func()
+}
+
+func (self *Job) Send(msn *task.Mission, artcl *model.Article) {
+	self.mtx.Lock()
+	if artcl.Unixtime() > self.latest {
+		self.latest = artcl.Unixtime()
+	}
+	self.items++
+	self.mtx.Unlock()
+
+	self.sending.Add(1)
+	go func(msn *task.Mission) {
+		defer msn.Done()
+		defer self.sending.Done()
+
+		select {
+		case <- msn.RecvCancel():
+			self.mtx.Lock()
+			self.dropped++
+			self.mtx.Unlock()
+		case self.ArticleCh <- artcl:
+		}
+	}(msn.New())
+}
+
+// Dropped returns the count of the sent articles which are not passed to ArticleCh because of the cancel.
+func (self *Job) Dropped() int {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.dropped
+}
+
+func (self *Job) Latest() int64 {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.latest
+}
+
+// Wait waits until the sent articles are passed to ArticleCh.
+func (self *Job) Wait() {
+	self.sending.Wait()
+}
+
+// AfterRecorded adds fn which is called after all the sent articles are recorded, e.g. to mark the read messages.
+// It is not called if some of them cannot be recorded, or on a dry run.
+func (self *Job) AfterRecorded(fn func()) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	self.commits = append(self.commits, fn)
+}
+
+// Commit calls the functions which are added by AfterRecorded.
+func (self *Job) Commit() {
+	self.mtx.Lock()
+	commits := self.commits
+	self.commits = nil
+	self.mtx.Unlock()
+
+	for _, fn := range commits {
+		fn()
+	}
+}
+
+// Items returns the count of the sent articles.
+func (self *Job) Items() int {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.items
+}
+
+// HttpStatus returns the status of the last response. It is 0 if the job has not read over HTTP.
+func (self *Job) HttpStatus() int {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.http_status
+}
+
+// Bytes returns the size of the read bodies of the responses.
+func (self *Job) Bytes() int64 {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.bytes
+}
+
+func (self *Job) setHttpStatus(status int) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	self.http_status = status
+}
+
+func (self *Job) addBytes(n int64) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	self.bytes += n
+}
+
+func Register(name string, c Collector) {
+	if err := registry.Add(name, c); err != nil {
+		panic(fmt.Sprintf("collector: %s", err))
+	}
+}
+
+func RegisterExtension(c Collector) {
+	extension_mtx.Lock()
+	defer extension_mtx.Unlock()
+
+	if c == nil {
+		panic("collector: extension is nil")
+	}
+	if extension != nil {
+		panic("collector: extension is already registered")
+	}
+	extension = c
+}
+
+// SetCommandTimeout sets the timeout of the command of a user created type. 0 is no limit.
+func SetCommandTimeout(d time.Duration) {
+	command_timeout.Store(int64(d))
+}
+
+func CommandTimeout() time.Duration {
+	return time.Duration(command_timeout.Load())
+}
+
+func Get(name string) (Collector, error) {
+	return registry.Get(name)
+}
+
+func Lookup(st *model.SourceType) (Collector, error) {
+	if !st.IsUserCreate() {
+		return registry.Get(st.Name())
+	}
+
+	extension_mtx.RLock()
+	defer extension_mtx.RUnlock()
+
+	if extension == nil {
+		return nil, fmt.Errorf("a collector for user created type '%s' is not registered", st.Name())
+	}
+	return extension, nil
+}
+
+func Names() []string {
+	return registry.Names()
+}
+
+func MakeFailedStatus(s string, msg ...any) *model.Status {
+	return &model.Status{
+		Unixtime: int(time.Now().Unix()),
+		IsSuccess: false,
+		Log: fmt.Sprintf(s, msg...),
+	}
+}
+
+func MakeSucceededStatus(s string, msg ...any) *model.Status {
+	return &model.Status{
+		Unixtime: int(time.Now().Unix()),
+		IsSuccess: true,
+		Log: fmt.Sprintf(s, msg...),
+	}
+}
+
+type index struct {
+	idx map[string]Collector
+	mtx *sync.RWMutex
+}
+
+func newIndex() *index {
+	return &index{
+		idx: map[string]Collector{},
+		mtx: new(sync.RWMutex),
+	}
+}
+
+func (self *index) Add(name string, c Collector) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	if c == nil {
+		return fmt.Errorf("'%s' is nil", name)
+	}
+	if _, ok := self.idx[name]; ok {
+		return fmt.Errorf("'%s' is already registered", name)
+	}
+	self.idx[name] = c
+	return nil
+}
+
+func (self *index) Get(name string) (Collector, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	c, ok := self.idx[name]
+	if !ok {
+		return nil, fmt.Errorf("a collector of '%s' is not registered", name)
+	}
+	return c, nil
+}
+
+func (self *index) Names() []string {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	names := make([]string, 0, len(self.idx))
+	for name, _ := range self.idx {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
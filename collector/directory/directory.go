@@ -0,0 +1,363 @@
+package directory
+
+import (
+	"os"
+	"fmt"
+	"time"
+	"bytes"
+	"strings"
+	"path/filepath"
+	"encoding/json"
+)
+
+import (
+	"github.com/l4go/task"
+	"github.com/fsnotify/fsnotify"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/model/external"
+	"github.com/hinoshiba/gwyneth/collector"
+	"github.com/hinoshiba/gwyneth/collector/rss"
+)
+
+const (
+	NAME = "directory"
+
+	DIR_WIP  = "wip"
+	DIR_DONE = "done"
+	DIR_DLQ  = "deadletter"
+
+	// a file is read after it is not written for SETTLE_DELAY.
+	SETTLE_DELAY = 2 * time.Second
+)
+
+func init() {
+	collector.Register(NAME, New())
+}
+
+// Collector reads the files which are dropped into the directory of the source value.
+// A file is moved to done/ after its articles are recorded, and a file which cannot be parsed is moved to deadletter/.
+// A file should be dropped atomically, e.g. written as a dotfile and renamed, but a file which is
+// still written is not read until it settles.
+type Collector struct {}
+
+func New() *Collector {
+	return &Collector{}
+}
+
+type dirs struct {
+	base string
+	wip  string
+	done string
+	dlq  string
+}
+
+func makeDirs(path string, create bool) (*dirs, error) {
+	base := filepath.Clean(path)
+	fi, err := os.Stat(base)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", base)
+	}
+
+	d := &dirs{
+		base: base,
+		wip: filepath.Join(base, DIR_WIP),
+		done: filepath.Join(base, DIR_DONE),
+		dlq: filepath.Join(base, DIR_DLQ),
+	}
+	if !create {
+		return d, nil
+	}
+	for _, p := range []string{d.wip, d.done, d.dlq} {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func (self *Collector) Collect(msn *task.Mission, job *collector.Job) *model.Status {
+	defer msn.Done()
+
+	d, err := makeDirs(job.Src.Value(), !job.DryRun)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = collector.Permanent(err)
+		}
+		return collector.MakeErrorStatus(err)
+	}
+
+	n, err := ReadDir(msn.New(), job, d)
+	if err != nil {
+		return collector.MakeErrorStatus(err)
+	}
+	return collector.MakeSucceededStatus("Succeeded: %d files", n)
+}
+
+func (self *Collector) Watch(msn *task.Mission, src *model.Source, notice func()) error {
+	defer msn.Done()
+
+	d, err := makeDirs(src.Value(), true)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := watcher.Add(d.base); err != nil {
+		return err
+	}
+
+	var settle <- chan time.Time
+	for {
+		select {
+		case <- msn.RecvCancel():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+				continue
+			}
+			if !isTarget(event.Name) {
+				continue
+			}
+			// waits for the last write of the file.
+			settle = time.After(SETTLE_DELAY)
+		case <- settle:
+			settle = nil
+			notice()
+		}
+	}
+}
+
+func ReadDir(msn *task.Mission, job *collector.Job, d *dirs) (int, error) {
+	defer msn.Done()
+
+	if job.DryRun {
+		return peekDir(msn, job, d)
+	}
+
+	// the files which were being read at the last stop are read again.
+	wips, err := os.ReadDir(d.wip)
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range wips {
+		if f.IsDir() {
+			continue
+		}
+		if err := os.Rename(filepath.Join(d.wip, f.Name()), filepath.Join(d.base, f.Name())); err != nil {
+			job.Logger.Warn("cant move wip to the directory: %s", err)
+		}
+	}
+
+	fs, err := os.ReadDir(d.base)
+	if err != nil {
+		return 0, err
+	}
+
+	cnt := 0
+	for _, f := range fs {
+		if task.IsCanceled(msn) {
+			return cnt, collector.ERR_CANCELED
+		}
+		if f.IsDir() || !isTarget(f.Name()) {
+			continue
+		}
+		if fi, err := f.Info(); err != nil || time.Since(fi.ModTime()) < SETTLE_DELAY {
+			// the file might be still written, it is read by the next collection.
+			continue
+		}
+
+		f_path := filepath.Join(d.base, f.Name())
+		wip_f_path := filepath.Join(d.wip, f.Name())
+		if err := os.Rename(f_path, wip_f_path); err != nil {
+			job.Logger.Error("Cannot mv wip file: %s -> %s: %s", f_path, wip_f_path, err)
+			continue
+		}
+
+		b, mtime, err := loadFile(wip_f_path)
+		if err != nil {
+			// the file in wip/ is read again by the next collection.
+			job.Logger.Warn("cannot read the dropped file: %s: %s", f_path, err)
+			continue
+		}
+		if err := sendFile(msn.New(), job, wip_f_path, b, mtime); err != nil {
+			job.Logger.Warn("cannot parse the dropped file: %s: %s", f_path, err)
+
+			dlq_f_path := uniquePath(d.dlq, f.Name())
+			if err := os.Rename(wip_f_path, dlq_f_path); err != nil {
+				job.Logger.Error("cannot move to dlq: src: %s, dst: %s, err: %s", wip_f_path, dlq_f_path, err)
+			}
+			continue
+		}
+		cnt++
+
+		// a file whose articles are not recorded stays in wip/, and it is read again by the next collection.
+		name := f.Name()
+		job.AfterRecorded(func() {
+			done_f_path := uniquePath(d.done, name)
+			if err := os.Rename(wip_f_path, done_f_path); err != nil {
+				job.Logger.Error("cannot move to done: src: %s, dst: %s, err: %s", wip_f_path, done_f_path, err)
+			}
+		})
+	}
+	return cnt, nil
+}
+
+// peekDir reads the files without moving them.
+func peekDir(msn *task.Mission, job *collector.Job, d *dirs) (int, error) {
+	fs, err := os.ReadDir(d.base)
+	if err != nil {
+		return 0, err
+	}
+
+	cnt := 0
+	for _, f := range fs {
+		if task.IsCanceled(msn) {
+			return cnt, nil
+		}
+		if f.IsDir() || !isTarget(f.Name()) {
+			continue
+		}
+
+		f_path := filepath.Join(d.base, f.Name())
+		if err := readFile(msn.New(), job, f_path); err != nil {
+			job.Logger.Warn("cannot read the dropped file: %s: %s", f_path, err)
+			continue
+		}
+		cnt++
+	}
+	return cnt, nil
+}
+
+// uniquePath returns the path of the name in the dir. If the file exists, a suffix is added to the name
+// so that a file of the same name is not overwritten.
+func uniquePath(dir string, name string) string {
+	path := filepath.Join(dir, name)
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return path
+	}
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		path = filepath.Join(dir, fmt.Sprintf("%s.%d.%d%s", base, time.Now().Unix(), i, ext))
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return path
+		}
+	}
+}
+
+func isTarget(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".xml", ".md":
+		return true
+	}
+	return false
+}
+
+func readFile(msn *task.Mission, job *collector.Job, path string) error {
+	defer msn.Done()
+
+	b, mtime, err := loadFile(path)
+	if err != nil {
+		return err
+	}
+	return sendFile(msn.New(), job, path, b, mtime)
+}
+
+func loadFile(path string) ([]byte, time.Time, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return b, fi.ModTime(), nil
+}
+
+// sendFile parses the content of the file, and sends the articles.
+func sendFile(msn *task.Mission, job *collector.Job, path string, b []byte, mtime time.Time) error {
+	defer msn.Done()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return sendJson(msn, job, b)
+	case ".xml":
+		return rss.New().Receive(msn.New(), job, bytes.NewReader(b))
+	case ".md":
+		return sendMarkdown(msn, job, filepath.Base(path), b, mtime)
+	}
+	return fmt.Errorf("unsupported file: %s", path)
+}
+
+// sendJson reads an article or a list of articles which is the json of external.Article.
+func sendJson(msn *task.Mission, job *collector.Job, b []byte) error {
+	var ex_artcls []*external.Article
+	if trimed := bytes.TrimSpace(b); len(trimed) > 0 && trimed[0] == '[' {
+		if err := json.Unmarshal(trimed, &ex_artcls); err != nil {
+			return err
+		}
+	} else {
+		var ex_artcl external.Article
+		if err := json.Unmarshal(trimed, &ex_artcl); err != nil {
+			return err
+		}
+		ex_artcls = append(ex_artcls, &ex_artcl)
+	}
+
+	now := time.Now().Unix()
+	for _, ex_artcl := range ex_artcls {
+		if ex_artcl == nil {
+			continue
+		}
+		utime := int64(ex_artcl.Timestamp)
+		if utime < 1 {
+			utime = now
+		}
+
+		artcl := model.NewArticle(nil, job.Src, ex_artcl.Title, ex_artcl.Body, ex_artcl.Link, utime, ex_artcl.Raw).
+			WithMeta(model.ImportExternalArticleMeta(ex_artcl))
+		job.Send(msn, artcl)
+	}
+	return nil
+}
+
+// sendMarkdown reads the first heading as the title. Without a heading, the file name is the title.
+func sendMarkdown(msn *task.Mission, job *collector.Job, fname string, b []byte, mtime time.Time) error {
+	content := strings.TrimSpace(string(b))
+	title := strings.TrimSuffix(fname, filepath.Ext(fname))
+	body := content
+
+	first, rest, _ := strings.Cut(content, "\n")
+	if strings.HasPrefix(first, "# ") {
+		title = strings.TrimSpace(strings.TrimPrefix(first, "# "))
+		body = strings.TrimSpace(rest)
+	}
+
+	artcl := model.NewArticle(nil, job.Src, title, body, "", mtime.Unix(), string(b))
+	job.Send(msn, artcl)
+	return nil
+}
@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"errors"
+	"time"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+)
+
+var (
+	// ERR_CANCELED is returned by a collector which is stopped before it reads all, e.g. by a restart of the collectors.
+	ERR_CANCELED = errors.New("the collection is canceled.")
+)
+
+// PermanentError is a failure which is not fixed by retrying, e.g. a missing feed or a broken content.
+type PermanentError struct {
+	Err error
+}
+
+func (self *PermanentError) Error() string {
+	return self.Err.Error()
+}
+
+func (self *PermanentError) Unwrap() error {
+	return self.Err
+}
+
+func Permanent(err error) error {
+	if err == nil || IsPermanent(err) {
+		return err
+	}
+	return &PermanentError{Err: err}
+}
+
+func IsPermanent(err error) bool {
+	var p_err *PermanentError
+	return errors.As(err, &p_err)
+}
+
+// MakeErrorStatus makes the failed status of the error. The other errors than PermanentError are transient.
+func MakeErrorStatus(err error) *model.Status {
+	return &model.Status{
+		Unixtime: int(time.Now().Unix()),
+		IsSuccess: false,
+		IsPermanent: IsPermanent(err),
+		Log: err.Error(),
+	}
+}
@@ -0,0 +1,147 @@
+package extension
+
+import (
+	"io"
+	"os/exec"
+	"fmt"
+	"time"
+	"bufio"
+	"context"
+	"strings"
+	"encoding/json"
+)
+
+import (
+	"github.com/l4go/task"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/model/external"
+	"github.com/hinoshiba/gwyneth/collector"
+)
+
+func init() {
+	collector.RegisterExtension(New())
+}
+
+const (
+	// the time to wait for the pipes after the command is killed.
+	WAIT_DELAY = 5 * time.Second
+)
+
+// Command runs the command of a user created type. It is killed at collector.CommandTimeout.
+type Command struct {}
+
+func New() *Command {
+	return &Command{}
+}
+
+func (self *Command) Collect(msn *task.Mission, job *collector.Job) *model.Status {
+	defer msn.Done()
+
+	cnt, err := self.run(msn.New(), job)
+	if err != nil {
+		return collector.MakeErrorStatus(err)
+	}
+	return collector.MakeSucceededStatus("Succeeded: %d articles", cnt)
+}
+
+func (self *Command) run(msn *task.Mission, job *collector.Job) (int, error) {
+	defer msn.Done()
+
+	st := job.Src.Type()
+	job.Logger.Debug("call '%s' '%s'", st.Name(), st.Command())
+
+	args := strings.SplitN(st.Command(), " ", 30)
+	if args[0] == "" {
+		return 0, fmt.Errorf("the command of '%s' is empty", st.Name())
+	}
+	c := args[0]
+	opts := []string{}
+	if len(args) > 1 {
+		opts = args[1:]
+	}
+	ctx := msn.AsContext()
+	if timeout := collector.CommandTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, c, opts...)
+	cmd.Stdin = strings.NewReader(job.Src.Value())
+	cmd.WaitDelay = WAIT_DELAY
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return 0, err
+	}
+
+	errout := ""
+	errout_done := make(chan struct{})
+	stderr_scanner := bufio.NewScanner(stderr)
+	go func() {
+		defer close(errout_done)
+
+		for stderr_scanner.Scan() {
+			errout += fmt.Sprintf("%s\n", stderr_scanner.Text())
+		}
+	}()
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	// the pipes are closed at the timeout too, because a child of the command might keep them open.
+	stop := context.AfterFunc(ctx, func() {
+		stdout.Close()
+		stderr.Close()
+	})
+	defer stop()
+
+	now := time.Now()
+	cnt := 0
+	stdout_scanner := bufio.NewScanner(stdout)
+	stdout_scanner.Buffer(make([]byte, 0, 64 * 1024), 16 * 1024 * 1024)
+	for stdout_scanner.Scan() {
+		line := strings.TrimSpace(stdout_scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var ex_artcl external.Article
+		if err := json.Unmarshal([]byte(line), &ex_artcl); err != nil {
+			job.Logger.Warn("convert errror: cannot parse the output of '%s': %s: '%s'", st.Name(), err, line)
+			continue
+		}
+
+		utime := int64(ex_artcl.Timestamp)
+		if utime < 1 {
+			utime = now.Unix()
+		}
+		artcl := model.NewArticle(nil, job.Src, ex_artcl.Title, ex_artcl.Body, ex_artcl.Link, utime, ex_artcl.Raw).
+			WithMeta(model.ImportExternalArticleMeta(&ex_artcl))
+		job.Send(msn, artcl)
+		cnt++
+	}
+	scan_err := stdout_scanner.Err()
+	if scan_err != nil {
+		io.Copy(io.Discard, stdout)
+	}
+	<- errout_done
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return cnt, fmt.Errorf("'%s' is killed by the timeout: %s", st.Name(), errout)
+		}
+		return cnt, fmt.Errorf("%s: %s", err, errout)
+	}
+	if scan_err != nil {
+		return cnt, scan_err
+	}
+	job.Logger.Debug("%s successed: %d articles, %s", st.Command(), cnt, errout)
+	return cnt, nil
+}
@@ -0,0 +1,200 @@
+package fulltext
+
+import (
+	"io"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+import (
+	"github.com/l4go/task"
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html/charset"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/collector"
+)
+
+const (
+	MAX_PAGE_SIZE = 16 * 1024 * 1024
+	MIN_PARAGRAPH = 25 // a paragraph shorter than this is not scored.
+)
+
+var (
+	REMOVE_TAGS = "script, style, noscript, template, iframe, svg, canvas, form, button, select, nav, header, footer, aside"
+
+	POSITIVE = regexp.MustCompile(`(?i)article|body|content|entry|hentry|main|page|post|text|blog|story`)
+	NEGATIVE = regexp.MustCompile(`(?i)comment|meta|footer|footnote|masthead|sidebar|sponsor|share|social|nav|menu|related|promo|banner|breadcrumb|widget|popup|cookie|\bads?\b`)
+
+	SPACES = regexp.MustCompile(`[ \t\r\f\v]+`)
+)
+
+// Fetch downloads the page of the link and extracts its main text.
+func Fetch(msn *task.Mission, job *collector.Job, link string) (string, error) {
+	defer msn.Done()
+
+	resp, err := collector.HttpGet(msn, job, link)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	r, err := charset.NewReader(io.LimitReader(resp.Body, MAX_PAGE_SIZE), resp.Header.Get("Content-Type"))
+	if err != nil {
+		return "", err
+	}
+	return Extract(r)
+}
+
+// Extract returns the main text of the html like readability.
+// The paragraphs are scored by their length and commas, and the score is given
+// to the parent and the grandparent. The text of the best element is returned.
+func Extract(r io.Reader) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return "", err
+	}
+	doc.Find(REMOVE_TAGS).Remove()
+	doc.Find("*").Each(func(_ int, s *goquery.Selection) {
+		if isUnlikely(s) {
+			s.Remove()
+		}
+	})
+
+	var best *goquery.Selection
+	var best_score float64
+	scores := make(map[*goquery.Selection]float64)
+	nodes := make(map[any]*goquery.Selection)
+
+	doc.Find("p, pre, td, blockquote, li").Each(func(_ int, p *goquery.Selection) {
+		text := normalize(p.Text())
+		if len([]rune(text)) < MIN_PARAGRAPH {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",") + strings.Count(text, "、")) +
+					min(float64(len([]rune(text))) / 100, 3)
+
+		for i, parent := range []*goquery.Selection{p.Parent(), p.Parent().Parent()} {
+			if parent.Length() < 1 || goquery.NodeName(parent) == "body" {
+				continue
+			}
+			key := parent.Get(0)
+			sel, ok := nodes[key]
+			if !ok {
+				sel = parent
+				nodes[key] = sel
+				scores[sel] = weight(sel)
+			}
+			if i == 0 {
+				scores[sel] += score
+			} else {
+				scores[sel] += score / 2
+			}
+		}
+	})
+
+	for sel, score := range scores {
+		// a container full of links is a menu.
+		score *= 1 - linkDensity(sel)
+		if best == nil || score > best_score {
+			best = sel
+			best_score = score
+		}
+	}
+
+	if best == nil {
+		best = doc.Find("article").First()
+	}
+	if best.Length() < 1 {
+		best = doc.Find("body")
+	}
+
+	text := toText(best)
+	if text == "" {
+		return "", fmt.Errorf("cannot find the text of the page.")
+	}
+	return text, nil
+}
+
+func isUnlikely(s *goquery.Selection) bool {
+	switch goquery.NodeName(s) {
+	case "html", "body", "article", "main":
+		return false
+	}
+	attr := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+	if strings.TrimSpace(attr) == "" {
+		return false
+	}
+	return NEGATIVE.MatchString(attr) && !POSITIVE.MatchString(attr)
+}
+
+func weight(s *goquery.Selection) float64 {
+	var w float64
+	switch goquery.NodeName(s) {
+	case "article", "main":
+		w += 10
+	case "div":
+		w += 5
+	case "pre", "td", "blockquote":
+		w += 3
+	case "ul", "ol", "dl", "dd", "dt", "li", "form":
+		w -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		w -= 5
+	}
+
+	for _, attr := range []string{s.AttrOr("class", ""), s.AttrOr("id", "")} {
+		if attr == "" {
+			continue
+		}
+		if NEGATIVE.MatchString(attr) {
+			w -= 25
+		}
+		if POSITIVE.MatchString(attr) {
+			w += 25
+		}
+	}
+	return w
+}
+
+func linkDensity(s *goquery.Selection) float64 {
+	total := len([]rune(normalize(s.Text())))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += len([]rune(normalize(a.Text())))
+	})
+	return float64(links) / float64(total)
+}
+
+// toText returns the text of the element with a blank line between the blocks.
+func toText(s *goquery.Selection) string {
+	s.Find("br").ReplaceWithHtml("\n")
+	s.Find("p, div, section, article, pre, blockquote, li, tr, h1, h2, h3, h4, h5, h6").Each(func(_ int, b *goquery.Selection) {
+		b.AppendHtml("\n\n")
+	})
+
+	lines := []string{}
+	blank := false
+	for _, line := range strings.Split(s.Text(), "\n") {
+		line = strings.TrimSpace(SPACES.ReplaceAllString(line, " "))
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func normalize(s string) string {
+	return strings.TrimSpace(SPACES.ReplaceAllString(strings.ReplaceAll(s, "\n", " "), " "))
+}
@@ -0,0 +1,228 @@
+package collector
+
+import (
+	"io"
+	"fmt"
+	"sync"
+	"time"
+	"strings"
+	"strconv"
+	"net/url"
+	"net/http"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+)
+
+import (
+	"github.com/l4go/task"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/consts"
+	"github.com/hinoshiba/gwyneth/model"
+)
+
+var (
+	USER_AGENT = "gwyneth/" + consts.VERSION
+)
+
+// HttpClient is the client and the headers which are used for the requests to a source.
+type HttpClient struct {
+	Client *http.Client
+	Header http.Header
+}
+
+// NewHttpClient makes the client with the option of a source. The credential is the value of the secret of the option.
+func NewHttpClient(opt *model.HttpOption, credential string) (*HttpClient, error) {
+	c := &HttpClient{
+		Client: http.DefaultClient,
+		Header: http.Header{},
+	}
+	if opt == nil {
+		return c, nil
+	}
+
+	for key, val := range opt.Headers {
+		c.Header.Set(key, val)
+	}
+	if opt.UserAgent != "" {
+		c.Header.Set("User-Agent", opt.UserAgent)
+	}
+
+	auth, err := model.ParseHttpAuth(opt.Auth)
+	if err != nil {
+		return nil, err
+	}
+	switch auth {
+	case model.HTTP_AUTH_BASIC:
+		token := base64.StdEncoding.EncodeToString([]byte(opt.Username + ":" + credential))
+		c.Header.Set("Authorization", "Basic " + token)
+	case model.HTTP_AUTH_BEARER:
+		c.Header.Set("Authorization", "Bearer " + credential)
+	}
+
+	if opt.Timeout < 0 {
+		return nil, fmt.Errorf("timeout is negative: %d", opt.Timeout)
+	}
+	if opt.Proxy == "" && !opt.SkipVerify && opt.CA == "" {
+		if opt.Timeout > 0 {
+			c.Client = &http.Client{Timeout: time.Duration(opt.Timeout) * time.Second}
+		}
+		return c, nil
+	}
+
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if opt.Proxy != "" {
+		proxy, err := url.Parse(opt.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse the proxy: %s", err)
+		}
+		tr.Proxy = http.ProxyURL(proxy)
+	}
+	if opt.SkipVerify || opt.CA != "" {
+		tls_cfg := &tls.Config{
+			InsecureSkipVerify: opt.SkipVerify,
+		}
+		if opt.CA != "" {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM([]byte(opt.CA)) {
+				return nil, fmt.Errorf("cannot read the certificates of the CA")
+			}
+			tls_cfg.RootCAs = pool
+		}
+		tr.TLSClientConfig = tls_cfg
+	}
+	c.Client = &http.Client{
+		Transport: tr,
+		Timeout: time.Duration(opt.Timeout) * time.Second,
+	}
+	return c, nil
+}
+
+func HttpGet(msn *task.Mission, job *Job, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(msn.AsContext(), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", USER_AGENT)
+
+	client := http.DefaultClient
+	if job.Http != nil {
+		for key, vals := range job.Http.Header {
+			req.Header[key] = vals
+		}
+		client = job.Http.Client
+	}
+
+	if job.State != nil {
+		if job.State.ETag != "" {
+			req.Header.Set("If-None-Match", job.State.ETag)
+		}
+		if job.State.LastModified != "" {
+			req.Header.Set("If-Modified-Since", job.State.LastModified)
+		}
+	}
+
+	release, err := politeness.Acquire(msn, client, req.URL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	job.setHttpStatus(resp.StatusCode)
+	resp.Body = &countingBody{ReadCloser: resp.Body, job: job, release: release}
+
+	if job.State != nil {
+		job.State.RetryAfter = 0
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return resp, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if t, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok && job.State != nil {
+			job.State.RetryAfter = t.Unix()
+		}
+		resp.Body.Close()
+
+		err := fmt.Errorf("unexpected response: %s", resp.Status)
+		switch resp.StatusCode {
+		case http.StatusNotFound, http.StatusGone:
+			return nil, Permanent(err)
+		}
+		return nil, err
+	}
+
+	if job.State != nil {
+		job.State.ETag = resp.Header.Get("ETag")
+		job.State.LastModified = resp.Header.Get("Last-Modified")
+	}
+	return resp, nil
+}
+
+// countingBody counts the read bytes of a response to the job. The turn of the host is released by Close.
+type countingBody struct {
+	io.ReadCloser
+	job     *Job
+	release func()
+	once    sync.Once
+}
+
+func (self *countingBody) Read(p []byte) (int, error) {
+	n, err := self.ReadCloser.Read(p)
+	self.job.addBytes(int64(n))
+	return n, err
+}
+
+func (self *countingBody) Close() error {
+	self.once.Do(self.release)
+	return self.ReadCloser.Close()
+}
+
+func IsNotModified(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusNotModified
+}
+
+func GetLinkHeader(resp *http.Response, rel string) string {
+	for _, h := range resp.Header.Values("Link") {
+		for _, link := range strings.Split(h, ",") {
+			parts := strings.Split(link, ";")
+			href := strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			for _, param := range parts[1:] {
+				key, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(key, "rel") {
+					continue
+				}
+				for _, r := range strings.Fields(strings.Trim(val, "\"")) {
+					if strings.EqualFold(r, rel) {
+						return href
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func parseRetryAfter(val string) (time.Time, bool) {
+	if val == "" {
+		return time.Time{}, false
+	}
+	if sec, err := strconv.Atoi(val); err == nil {
+		if sec < 0 {
+			return time.Time{}, false
+		}
+		return time.Now().Add(time.Duration(sec) * time.Second), true
+	}
+	t, err := http.ParseTime(val)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,188 @@
+package jsonapi
+
+import (
+	"fmt"
+	"time"
+	"errors"
+	"strconv"
+	"encoding/json"
+)
+
+import (
+	"github.com/l4go/task"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/collector"
+)
+
+const (
+	NAME = "jsonapi"
+)
+
+func init() {
+	collector.Register(NAME, New())
+}
+
+// Config is the value of a jsonapi source.
+// Items selects the elements from the response, and the other paths are evaluated in each element.
+type Config struct {
+	Url             string `json:"url"`
+	Items           string `json:"items"`
+	Id              string `json:"id"`
+	Title           string `json:"title"`
+	Body            string `json:"body"`
+	Link            string `json:"link"`
+	Timestamp       string `json:"timestamp"`
+	TimestampFormat string `json:"timestamp_format"`
+}
+
+func ParseConfig(val string) (*Config, error) {
+	var cfg Config
+	if err := json.Unmarshal([]byte(val), &cfg); err != nil {
+		return nil, fmt.Errorf("the source value is not a json of the jsonapi config: %s", err)
+	}
+	if cfg.Url == "" {
+		return nil, fmt.Errorf("url is empty")
+	}
+	if cfg.Items == "" {
+		cfg.Items = "$[*]"
+	}
+	if cfg.Title == "" {
+		return nil, fmt.Errorf("title path is empty")
+	}
+	return &cfg, nil
+}
+
+type Collector struct {}
+
+func New() *Collector {
+	return &Collector{}
+}
+
+func (self *Collector) Collect(msn *task.Mission, job *collector.Job) *model.Status {
+	defer msn.Done()
+
+	cfg, err := ParseConfig(job.Src.Value())
+	if err != nil {
+		return collector.MakeErrorStatus(collector.Permanent(err))
+	}
+
+	n, err := GetItems(msn.New(), job, cfg)
+	if err != nil {
+		return collector.MakeErrorStatus(err)
+	}
+	if n < 0 {
+		return collector.MakeSucceededStatus("Succeeded: not modified")
+	}
+	return collector.MakeSucceededStatus("Succeeded: %d items", n)
+}
+
+// GetItems sends the articles of the response, and returns the count of them. It returns -1 if the response is not modified.
+func GetItems(msn *task.Mission, job *collector.Job, cfg *Config) (int, error) {
+	defer msn.Done()
+
+	resp, err := collector.HttpGet(msn, job, cfg.Url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if collector.IsNotModified(resp) {
+		return -1, nil
+	}
+
+	var doc any
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		var syntax_err *json.SyntaxError
+		if errors.As(err, &syntax_err) {
+			return 0, collector.Permanent(err)
+		}
+		return 0, err
+	}
+
+	elems, err := Lookup(doc, cfg.Items)
+	if err != nil {
+		return 0, collector.Permanent(err)
+	}
+
+	now := time.Now()
+	cnt := 0
+	for _, elem := range elems {
+		artcl, err := cfg.MakeArticle(job.Src, elem, now)
+		if err != nil {
+			job.Logger.Warn("cannot convert the element of '%s': %s", cfg.Url, err)
+			continue
+		}
+
+		job.Send(msn, artcl)
+		cnt++
+	}
+	return cnt, nil
+}
+
+// MakeArticle maps the element to an article. The id is the guid of the article, and the element itself is stored as the raw.
+func (self *Config) MakeArticle(src *model.Source, elem any, now time.Time) (*model.Article, error) {
+	title, err := LookupString(elem, self.Title)
+	if err != nil {
+		return nil, err
+	}
+	body, err := LookupString(elem, self.Body)
+	if err != nil {
+		return nil, err
+	}
+	link, err := LookupString(elem, self.Link)
+	if err != nil {
+		return nil, err
+	}
+	id, err := LookupString(elem, self.Id)
+	if err != nil {
+		return nil, err
+	}
+	if title == "" && body == "" {
+		return nil, fmt.Errorf("title and body are empty")
+	}
+
+	pubdate := now
+	if self.Timestamp != "" {
+		t, err := self.lookupTime(elem)
+		if err != nil {
+			return nil, err
+		}
+		if !t.IsZero() {
+			pubdate = t
+		}
+	}
+
+	raw, err := json.Marshal(elem)
+	if err != nil {
+		return nil, err
+	}
+	artcl := model.NewArticle(nil, src, title, body, link, pubdate.Unix(), string(raw))
+	return artcl.WithMeta(&model.ArticleMeta{Guid: id}), nil
+}
+
+func (self *Config) lookupTime(elem any) (time.Time, error) {
+	vals, err := Lookup(elem, self.Timestamp)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(vals) < 1 || vals[0] == nil {
+		return time.Time{}, nil
+	}
+
+	val := ToString(vals[0])
+	if self.TimestampFormat == "" {
+		if num, err := strconv.ParseFloat(val, 64); err == nil {
+			if num > 1e12 {
+				// milliseconds
+				return time.UnixMilli(int64(num)), nil
+			}
+			return time.Unix(int64(num), 0), nil
+		}
+	}
+	return collector.ParseTime(val, self.TimestampFormat)
+}
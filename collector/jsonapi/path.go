@@ -0,0 +1,159 @@
+package jsonapi
+
+import (
+	"fmt"
+	"strings"
+	"strconv"
+	"encoding/json"
+)
+
+type step struct {
+	key    string
+	index  int
+	is_idx bool
+	all    bool
+}
+
+// Lookup evaluates a JSONPath-style expression against the value which is decoded by encoding/json.
+// The supported expressions are '$', '.key', "['key']", '[n]', '[*]' and '.*'. The leading '$' is optional.
+func Lookup(v any, path string) ([]any, error) {
+	steps, err := parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cur := []any{v}
+	for _, s := range steps {
+		next := []any{}
+		for _, c := range cur {
+			next = append(next, s.apply(c)...)
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// LookupString returns the first value of the path as a string. An empty path returns an empty string.
+func LookupString(v any, path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	vals, err := Lookup(v, path)
+	if err != nil {
+		return "", err
+	}
+	if len(vals) < 1 {
+		return "", nil
+	}
+	return ToString(vals[0]), nil
+}
+
+func ToString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case json.Number:
+		return val.String()
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func (self *step) apply(v any) []any {
+	switch val := v.(type) {
+	case map[string]any:
+		if self.all {
+			ret := []any{}
+			for _, c := range val {
+				ret = append(ret, c)
+			}
+			return ret
+		}
+		if self.is_idx {
+			return nil
+		}
+		c, ok := val[self.key]
+		if !ok {
+			return nil
+		}
+		return []any{c}
+	case []any:
+		if self.all {
+			return val
+		}
+		if !self.is_idx {
+			return nil
+		}
+		idx := self.index
+		if idx < 0 {
+			idx += len(val)
+		}
+		if idx < 0 || idx >= len(val) {
+			return nil
+		}
+		return []any{val[idx]}
+	}
+	return nil
+}
+
+func parse(path string) ([]*step, error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+
+	steps := []*step{}
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			key := path[:end]
+			path = path[end:]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in the path")
+			}
+			if key == "*" {
+				steps = append(steps, &step{all: true})
+				continue
+			}
+			steps = append(steps, &step{key: key})
+		case '[':
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed bracket in the path")
+			}
+			inner := strings.TrimSpace(path[1:end])
+			path = path[end + 1:]
+
+			if inner == "*" {
+				steps = append(steps, &step{all: true})
+				continue
+			}
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner) - 1] == inner[0] {
+				steps = append(steps, &step{key: inner[1:len(inner) - 1]})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index in the path: '%s'", inner)
+			}
+			steps = append(steps, &step{index: idx, is_idx: true})
+		default:
+			// a path without the leading '$.' is relative to the element.
+			path = "." + path
+		}
+	}
+	return steps, nil
+}
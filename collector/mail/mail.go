@@ -0,0 +1,287 @@
+package mail
+
+import (
+	"os"
+	"io"
+	"fmt"
+	"sort"
+	"bytes"
+	"strings"
+	"strconv"
+	"path/filepath"
+)
+
+import (
+	"github.com/l4go/task"
+	"github.com/fsnotify/fsnotify"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/collector"
+)
+
+const (
+	NAME = "mailbox"
+
+	MAILDIR_INFO = ":2,"
+	MAILDIR_SEEN = "S"
+)
+
+func init() {
+	collector.Register(NAME, New())
+}
+
+// Collector reads a Maildir or a mbox file which is the value of the source.
+// A message of the Maildir is marked as seen after it is recorded, and the read position of the mbox is kept as the cursor.
+type Collector struct {}
+
+func New() *Collector {
+	return &Collector{}
+}
+
+func (self *Collector) Collect(msn *task.Mission, job *collector.Job) *model.Status {
+	defer msn.Done()
+
+	path := filepath.Clean(job.Src.Value())
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = collector.Permanent(err)
+		}
+		return collector.MakeErrorStatus(err)
+	}
+
+	var n int
+	if fi.IsDir() {
+		n, err = ReadMaildir(msn.New(), job, path)
+	} else {
+		n, err = ReadMbox(msn.New(), job, path)
+	}
+	if err != nil {
+		return collector.MakeErrorStatus(err)
+	}
+	return collector.MakeSucceededStatus("Succeeded: %d messages", n)
+}
+
+func (self *Collector) Watch(msn *task.Mission, src *model.Source, notice func()) error {
+	defer msn.Done()
+
+	path := filepath.Clean(src.Value())
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if fi.IsDir() {
+		path = filepath.Join(path, "new")
+	}
+	if err := watcher.Add(path); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <- msn.RecvCancel():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+				continue
+			}
+			notice()
+		}
+	}
+}
+
+func ReadMaildir(msn *task.Mission, job *collector.Job, path string) (int, error) {
+	defer msn.Done()
+
+	path_cur := filepath.Join(path, "cur")
+	if _, err := os.Stat(path_cur); err != nil {
+		return 0, fmt.Errorf("not a maildir: %s", err)
+	}
+
+	cnt := 0
+	for _, sub := range []string{"new", "cur"} {
+		dir := filepath.Join(path, sub)
+		fs, err := os.ReadDir(dir)
+		if err != nil {
+			return cnt, err
+		}
+
+		for _, f := range fs {
+			if task.IsCanceled(msn) {
+				return cnt, collector.ERR_CANCELED
+			}
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+			name, flags := splitMaildirName(f.Name())
+			if strings.Contains(flags, MAILDIR_SEEN) {
+				continue
+			}
+
+			f_path := filepath.Join(dir, f.Name())
+			if err := readMessageFile(msn, job, f_path); err != nil {
+				// the message is read again by the next collection.
+				job.Logger.Warn("cannot read the message: %s: %s", f_path, err)
+				continue
+			}
+			cnt++
+			if job.DryRun {
+				continue
+			}
+
+			seen_path := filepath.Join(path_cur, name + MAILDIR_INFO + addFlag(flags, MAILDIR_SEEN))
+			job.AfterRecorded(func() {
+				if err := os.Rename(f_path, seen_path); err != nil {
+					job.Logger.Warn("cannot mark the message: %s: %s", f_path, err)
+				}
+			})
+		}
+	}
+	return cnt, nil
+}
+
+func readMessageFile(msn *task.Mission, job *collector.Job, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	m, err := ParseMessage(f)
+	if err != nil {
+		return err
+	}
+	job.Send(msn, m.Article(job.Src))
+	return nil
+}
+
+func splitMaildirName(fname string) (string, string) {
+	idx := strings.Index(fname, MAILDIR_INFO)
+	if idx < 0 {
+		return fname, ""
+	}
+	return fname[:idx], fname[idx + len(MAILDIR_INFO):]
+}
+
+func addFlag(flags string, flag string) string {
+	if strings.Contains(flags, flag) {
+		return flags
+	}
+	fs := strings.Split(flags + flag, "")
+	sort.Strings(fs)
+	return strings.Join(fs, "")
+}
+
+// ReadMbox reads the messages after the cursor, and moves the cursor to the end of the file.
+// The last message is not read until the file ends with a blank line, because it might be still written.
+func ReadMbox(msn *task.Mission, job *collector.Job, path string) (int, error) {
+	defer msn.Done()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	var offset int64
+	if job.State != nil && job.State.Cursor != "" {
+		offset, _ = strconv.ParseInt(job.State.Cursor, 10, 64)
+	}
+	if offset > fi.Size() {
+		// the mbox is truncated or replaced.
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return 0, err
+	}
+
+	end := len(b)
+	if !bytes.HasSuffix(b, []byte("\n\n")) {
+		end = lastMboxStart(b)
+	}
+
+	cnt := 0
+	for _, raw := range splitMbox(b[:end]) {
+		if task.IsCanceled(msn) {
+			return cnt, collector.ERR_CANCELED
+		}
+		m, err := ParseMessage(bytes.NewReader(raw))
+		if err != nil {
+			job.Logger.Warn("cannot read a message of %s: %s", path, err)
+			continue
+		}
+		job.Send(msn, m.Article(job.Src))
+		cnt++
+	}
+
+	if job.State != nil {
+		job.State.Cursor = strconv.FormatInt(offset + int64(end), 10)
+	}
+	return cnt, nil
+}
+
+// lastMboxStart returns the offset of the "From " line of the last message.
+func lastMboxStart(b []byte) int {
+	if idx := bytes.LastIndex(b, []byte("\nFrom ")); idx >= 0 {
+		return idx + 1
+	}
+	if bytes.HasPrefix(b, []byte("From ")) {
+		return 0
+	}
+	return len(b)
+}
+
+func splitMbox(b []byte) [][]byte {
+	msgs := [][]byte{}
+
+	var cur *bytes.Buffer
+	for _, line := range bytes.SplitAfter(b, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("From ")) {
+			if cur != nil && cur.Len() > 0 {
+				msgs = append(msgs, cur.Bytes())
+			}
+			cur = new(bytes.Buffer)
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+
+		// unescapes the mboxrd quoting.
+		if bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) && line[0] == '>' {
+			line = line[1:]
+		}
+		cur.Write(line)
+	}
+	if cur != nil && cur.Len() > 0 {
+		msgs = append(msgs, cur.Bytes())
+	}
+	return msgs
+}
@@ -0,0 +1,173 @@
+package mail
+
+import (
+	"io"
+	"fmt"
+	"time"
+	"bytes"
+	"strings"
+	"net/mail"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"encoding/base64"
+)
+
+import (
+	"golang.org/x/net/html/charset"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+)
+
+const (
+	MAX_MESSAGE_SIZE = 32 * 1024 * 1024
+)
+
+var (
+	word_decoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+)
+
+type Message struct {
+	Subject   string
+	From      string
+	Body      string
+	Date      time.Time
+	MessageId string
+	Raw       string
+}
+
+// ParseMessage reads a RFC 5322 message. The text part is preferred to the html part as the body.
+func ParseMessage(r io.Reader) (*Message, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, MAX_MESSAGE_SIZE))
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := word_decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	from, err := word_decoder.DecodeHeader(msg.Header.Get("From"))
+	if err != nil {
+		from = msg.Header.Get("From")
+	}
+
+	date, err := msg.Header.Date()
+	if err != nil {
+		date = time.Now()
+	}
+
+	text, html, err := readBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	body := text
+	if body == "" {
+		body = html
+	}
+
+	return &Message{
+		Subject: strings.TrimSpace(subject),
+		From: strings.TrimSpace(from),
+		Body: strings.TrimSpace(body),
+		Date: date,
+		MessageId: strings.Trim(strings.TrimSpace(msg.Header.Get("Message-Id")), "<>"),
+		Raw: string(raw),
+	}, nil
+}
+
+// Article converts the message. The link is the 'mid:' url of the Message-ID.
+func (self *Message) Article(src *model.Source) *model.Article {
+	link := ""
+	if self.MessageId != "" {
+		link = "mid:" + self.MessageId
+	}
+	artcl := model.NewArticle(nil, src, self.Subject, self.Body, link, self.Date.Unix(), self.Raw)
+	return artcl.WithMeta(&model.ArticleMeta{Author: self.From, Guid: self.MessageId})
+}
+
+func readBody(ctype string, encoding string, r io.Reader) (string, string, error) {
+	if ctype == "" {
+		ctype = "text/plain"
+	}
+	mtype, params, err := mime.ParseMediaType(ctype)
+	if err != nil {
+		mtype = "text/plain"
+		params = map[string]string{}
+	}
+
+	if strings.HasPrefix(mtype, "multipart/") {
+		var text string
+		var html string
+
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return text, html, err
+			}
+			if strings.HasPrefix(part.Header.Get("Content-Disposition"), "attachment") {
+				continue
+			}
+
+			t, h, err := readBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return text, html, err
+			}
+			if text == "" {
+				text = t
+			}
+			if html == "" {
+				html = h
+			}
+		}
+		return text, html, nil
+	}
+
+	if mtype != "text/plain" && mtype != "text/html" {
+		return "", "", nil
+	}
+
+	body, err := decode(r, encoding, params["charset"])
+	if err != nil {
+		return "", "", err
+	}
+	if mtype == "text/html" {
+		return "", body, nil
+	}
+	return body, "", nil
+}
+
+func decode(r io.Reader, encoding string, cset string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	}
+
+	if cset != "" {
+		cr, err := charset.NewReaderLabel(cset, r)
+		if err != nil {
+			return "", fmt.Errorf("unsupported charset: '%s'", cset)
+		}
+		r = cr
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
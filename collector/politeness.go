@@ -0,0 +1,196 @@
+package collector
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"strings"
+	"net/url"
+	"net/http"
+)
+
+import (
+	"github.com/l4go/task"
+)
+
+const (
+	ROBOTS_CACHE_TTL       = 1 * time.Hour
+	ROBOTS_ERROR_CACHE_TTL = 10 * time.Minute
+	ROBOTS_MAX_SIZE        = 512 * 1024
+	ROBOTS_AGENT           = "gwyneth"
+)
+
+var (
+	politeness = newPoliteness()
+)
+
+// SetPoliteness sets the limits of the requests to a host. concurrency is the number of the requests
+// at the same time and delay is the interval between the starts of the requests. 0 is no limit.
+// With robots, the robots.txt of the host is honored, and its Crawl-delay makes the delay longer.
+func SetPoliteness(concurrency int, delay time.Duration, robots bool) {
+	politeness.Set(concurrency, delay, robots)
+}
+
+type host struct {
+	slots chan struct{}
+	next  time.Time
+
+	robots         *robots
+	robots_expires time.Time
+	robots_mtx     sync.Mutex
+}
+
+type hostLimiter struct {
+	concurrency int
+	delay       time.Duration
+	robots      bool
+
+	hosts map[string]*host
+	mtx   *sync.Mutex
+}
+
+func newPoliteness() *hostLimiter {
+	return &hostLimiter{
+		hosts: make(map[string]*host),
+		mtx: new(sync.Mutex),
+	}
+}
+
+func (self *hostLimiter) Set(concurrency int, delay time.Duration, robots bool) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	self.concurrency = concurrency
+	self.delay = delay
+	self.robots = robots
+	self.hosts = make(map[string]*host)
+}
+
+func (self *hostLimiter) get(name string) *host {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	h, ok := self.hosts[name]
+	if !ok {
+		h = &host{}
+		if self.concurrency > 0 {
+			h.slots = make(chan struct{}, self.concurrency)
+		}
+		self.hosts[name] = h
+	}
+	return h
+}
+
+// Acquire waits for the turn of the request to the url, and returns the function to release it.
+func (self *hostLimiter) Acquire(msn *task.Mission, client *http.Client, u *url.URL) (func(), error) {
+	h := self.get(strings.ToLower(u.Host))
+
+	if h.slots != nil {
+		select {
+		case <- msn.RecvCancel():
+			return nil, fmt.Errorf("canceled")
+		case h.slots <- struct{}{}:
+		}
+	}
+	release := func() {
+		if h.slots != nil {
+			<- h.slots
+		}
+	}
+
+	self.mtx.Lock()
+	delay := self.delay
+	use_robots := self.robots
+	self.mtx.Unlock()
+
+	var rbt *robots
+	if use_robots {
+		rbt = h.getRobots(msn, client, u, func() {
+			self.wait(msn, h, delay)
+		})
+		if rbt.Delay > delay {
+			delay = rbt.Delay
+		}
+		if !rbt.IsAllowed(ROBOTS_AGENT, u) {
+			release()
+			return nil, Permanent(fmt.Errorf("disallowed by robots.txt: %s", u.String()))
+		}
+	}
+
+	if err := self.wait(msn, h, delay); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// wait waits the delay from the start of the previous request to the host.
+func (self *hostLimiter) wait(msn *task.Mission, h *host, delay time.Duration) error {
+	self.mtx.Lock()
+	now := time.Now()
+	start := h.next
+	if start.Before(now) {
+		start = now
+	}
+	h.next = start.Add(delay)
+	self.mtx.Unlock()
+
+	d := start.Sub(now)
+	if d <= 0 {
+		return nil
+	}
+	tm := time.NewTimer(d)
+	defer tm.Stop()
+	select {
+	case <- msn.RecvCancel():
+		return fmt.Errorf("canceled")
+	case <- tm.C:
+	}
+	return nil
+}
+
+// getRobots returns the cached robots.txt of the host, or reads it. wait is called before reading it.
+func (self *host) getRobots(msn *task.Mission, client *http.Client, u *url.URL, wait func()) *robots {
+	self.robots_mtx.Lock()
+	defer self.robots_mtx.Unlock()
+
+	if self.robots != nil && time.Now().Before(self.robots_expires) {
+		return self.robots
+	}
+
+	wait()
+	rbt, err := fetchRobots(msn, client, u)
+	if err != nil {
+		// the host is read as usual while its robots.txt is not available.
+		self.robots = &robots{}
+		self.robots_expires = time.Now().Add(ROBOTS_ERROR_CACHE_TTL)
+		return self.robots
+	}
+	self.robots = rbt
+	self.robots_expires = time.Now().Add(ROBOTS_CACHE_TTL)
+	return self.robots
+}
+
+func fetchRobots(msn *task.Mission, client *http.Client, u *url.URL) (*robots, error) {
+	r_url := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(msn.AsContext(), http.MethodGet, r_url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", USER_AGENT)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return parseRobots(http.MaxBytesReader(nil, resp.Body, ROBOTS_MAX_SIZE))
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		// the host has no rules.
+		return &robots{}, nil
+	}
+	return nil, fmt.Errorf("unexpected response: %s", resp.Status)
+}
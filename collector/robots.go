@@ -0,0 +1,142 @@
+package collector
+
+import (
+	"io"
+	"bufio"
+	"strings"
+	"strconv"
+	"net/url"
+	"time"
+)
+
+// robots is the rules of a robots.txt which apply to gwyneth. An empty one allows everything.
+type robots struct {
+	Rules []*robotsRule
+	Delay time.Duration // Crawl-delay.
+}
+
+type robotsRule struct {
+	allow bool
+	path  string
+}
+
+type robotsGroup struct {
+	agents []string
+	rules  []*robotsRule
+	delay  time.Duration
+}
+
+// parseRobots reads the groups of a robots.txt, and keeps the group for ROBOTS_AGENT, or for '*' without it.
+func parseRobots(r io.Reader) (*robots, error) {
+	groups := []*robotsGroup{}
+	var cur *robotsGroup
+	in_agents := false
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+
+		switch key {
+		case "user-agent":
+			if !in_agents {
+				cur = &robotsGroup{}
+				groups = append(groups, cur)
+				in_agents = true
+			}
+			cur.agents = append(cur.agents, strings.ToLower(val))
+			continue
+		case "allow", "disallow":
+			if cur != nil && val != "" {
+				cur.rules = append(cur.rules, &robotsRule{allow: key == "allow", path: val})
+			}
+		case "crawl-delay":
+			if cur != nil {
+				if sec, err := strconv.ParseFloat(val, 64); err == nil && sec > 0 {
+					cur.delay = time.Duration(sec * float64(time.Second))
+				}
+			}
+		}
+		in_agents = false
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	var matched *robotsGroup
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent == ROBOTS_AGENT {
+				return &robots{Rules: g.rules, Delay: g.delay}, nil
+			}
+			if agent == "*" && matched == nil {
+				matched = g
+			}
+		}
+	}
+	if matched == nil {
+		return &robots{}, nil
+	}
+	return &robots{Rules: matched.rules, Delay: matched.delay}, nil
+}
+
+// IsAllowed returns whether the url can be read. The longest matching rule wins, and Allow wins a tie.
+func (self *robots) IsAllowed(agent string, u *url.URL) bool {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	allow := true
+	longest := -1
+	for _, rule := range self.Rules {
+		if !matchRobotsPath(rule.path, path) {
+			continue
+		}
+		if len(rule.path) > longest || (len(rule.path) == longest && rule.allow) {
+			allow = rule.allow
+			longest = len(rule.path)
+		}
+	}
+	return allow
+}
+
+// matchRobotsPath matches the path with the pattern of a rule. '*' is any characters and '$' is the end of the path.
+func matchRobotsPath(pattern string, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+
+	if anchored {
+		last := parts[len(parts) - 1]
+		if !strings.HasSuffix(rest, last) {
+			return false
+		}
+		rest = rest[:len(rest) - len(last)]
+		parts = parts[:len(parts) - 1]
+	}
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i + len(part):]
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+package rss
+
+import (
+	"io"
+	"bytes"
+	"strings"
+	"strconv"
+	"net/url"
+)
+
+import (
+	"github.com/l4go/task"
+	"github.com/mmcdole/gofeed"
+	"github.com/mmcdole/gofeed/rss"
+	"github.com/mmcdole/gofeed/atom"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/collector"
+)
+
+const (
+	WORDPRESS_PAGE_PARAM = "paged"
+)
+
+var (
+	// the relations of RFC 5005 which point to the older entries, in order of preference.
+	PAGE_RELS = []string{"prev-archive", "next"}
+)
+
+// Backfill reads the feed and its older pages. The pages are followed by the links of RFC 5005
+// (prev-archive of an archived feed, or next of a paged feed), or by ?paged=N of WordPress if the feed has no links.
+// It stops at the limit, at a page which cannot be read, or at a page which has no new items.
+// A link to another origin is not followed, because the requests carry the headers and the credential of the source.
+func (self *Collector) Backfill(msn *task.Mission, job *collector.Job, limit int) *model.Status {
+	defer msn.Done()
+
+	page_url := job.Src.Value()
+	visited := map[string]struct{}{}
+	seen := map[string]struct{}{}
+	paged := 0
+	pages := 0
+	for pages < limit {
+		if task.IsCanceled(msn) {
+			break
+		}
+		visited[page_url] = struct{}{}
+
+		feed, next, err := getPage(msn.New(), job, page_url)
+		if err != nil {
+			if pages == 0 {
+				return collector.MakeErrorStatus(err)
+			}
+			// e.g. WordPress returns 404 for the page after the last one.
+			job.Logger.Debug("the backfill of '%s' is stopped at '%s': %s", job.Src.Title(), page_url, err)
+			break
+		}
+
+		items := []*gofeed.Item{}
+		for _, item := range feed.Items {
+			key := itemKey(item)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			items = append(items, item)
+		}
+		if len(items) < 1 {
+			// a server which ignores the page returns the same items again.
+			break
+		}
+		feed.Items = items
+		sendItems(msn, job, feed)
+		pages++
+
+		if paged > 0 || (pages == 1 && next == "") {
+			if paged == 0 {
+				paged = 1
+			}
+			paged++
+			next, err = makePagedUrl(job.Src.Value(), paged)
+			if err != nil {
+				return collector.MakeErrorStatus(collector.Permanent(err))
+			}
+		}
+		if next == "" {
+			break
+		}
+		if _, ok := visited[next]; ok {
+			break
+		}
+		if !isSameOrigin(job.Src.Value(), next) {
+			job.Logger.Warn("the backfill of '%s' does not follow the link to another origin: '%s'", job.Src.Title(), next)
+			break
+		}
+		page_url = next
+	}
+	return collector.MakeSucceededStatus("Succeeded: backfilled %d pages", pages)
+}
+
+// getPage reads a page of the feed, and returns the link to the older page if it is declared.
+func getPage(msn *task.Mission, job *collector.Job, page_url string) (*gofeed.Feed, string, error) {
+	defer msn.Done()
+
+	resp, err := collector.HttpGet(msn, job, page_url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	feed, err := parseFeed(bytes.NewReader(b), nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	for _, rel := range PAGE_RELS {
+		if next = collector.GetLinkHeader(resp, rel); next != "" {
+			break
+		}
+	}
+	if next == "" {
+		next = getPageLink(b)
+	}
+	if next == "" {
+		return feed, "", nil
+	}
+
+	ref, err := url.Parse(next)
+	if err != nil {
+		return feed, "", nil
+	}
+	return feed, resp.Request.URL.ResolveReference(ref).String(), nil
+}
+
+// getPageLink returns the link to the older page in the feed. It is atom:link in a RSS.
+func getPageLink(b []byte) string {
+	links := map[string]string{}
+	switch gofeed.DetectFeedType(bytes.NewReader(b)) {
+	case gofeed.FeedTypeRSS:
+		rss_feed, err := (&rss.Parser{}).Parse(bytes.NewReader(b))
+		if err != nil {
+			return ""
+		}
+		for _, elems := range rss_feed.Extensions {
+			for _, link := range elems["link"] {
+				links[link.Attrs["rel"]] = link.Attrs["href"]
+			}
+		}
+	case gofeed.FeedTypeAtom:
+		atom_feed, err := (&atom.Parser{}).Parse(bytes.NewReader(b))
+		if err != nil {
+			return ""
+		}
+		for _, link := range atom_feed.Links {
+			links[link.Rel] = link.Href
+		}
+	}
+
+	for _, rel := range PAGE_RELS {
+		if href := links[rel]; href != "" {
+			return href
+		}
+	}
+	return ""
+}
+
+func isSameOrigin(base string, link string) bool {
+	b, err := url.Parse(base)
+	if err != nil {
+		return false
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(b.Scheme, u.Scheme) && strings.EqualFold(b.Host, u.Host)
+}
+
+func makePagedUrl(base string, page int) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set(WORDPRESS_PAGE_PARAM, strconv.Itoa(page))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func itemKey(item *gofeed.Item) string {
+	if item.GUID != "" {
+		return item.GUID
+	}
+	if item.Link != "" {
+		return item.Link
+	}
+	return item.Title
+}
@@ -0,0 +1,127 @@
+package rss
+
+import (
+	"io"
+	"fmt"
+	"bytes"
+	"strings"
+	"net/url"
+)
+
+import (
+	"github.com/l4go/task"
+	"github.com/PuerkitoBio/goquery"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/collector"
+)
+
+const (
+	MAX_DISCOVER_SIZE = 16 * 1024 * 1024
+)
+
+var (
+	FEED_TYPES = map[string]struct{}{
+		"application/rss+xml":  struct{}{},
+		"application/atom+xml": struct{}{},
+		"application/feed+json": struct{}{},
+	}
+)
+
+type Candidate struct {
+	Url   string `json:"url"`
+	Title string `json:"title"`
+	Type  string `json:"type"`
+}
+
+// Discover fetches the url with the client of the job. If the url is a feed, it returns the feed itself.
+// Otherwise it returns the feeds which are linked by the page with <link rel="alternate">.
+func Discover(msn *task.Mission, job *collector.Job, u string) (*Candidate, []*Candidate, error) {
+	defer msn.Done()
+
+	resp, err := collector.HttpGet(msn, job, u)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, MAX_DISCOVER_SIZE))
+	if err != nil {
+		return nil, nil, err
+	}
+	if feed, err := parseFeed(bytes.NewReader(b), nil); err == nil {
+		return &Candidate{Url: u, Title: feed.Title}, nil, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse the page: %s", err)
+	}
+
+	cands := []*Candidate{}
+	known := make(map[string]struct{})
+	doc.Find("link[rel][href]").Each(func(_ int, s *goquery.Selection) {
+		if !hasRel(s.AttrOr("rel", ""), "alternate") {
+			return
+		}
+		typ, _, _ := strings.Cut(s.AttrOr("type", ""), ";")
+		typ = strings.ToLower(strings.TrimSpace(typ))
+		if _, ok := FEED_TYPES[typ]; !ok {
+			return
+		}
+
+		href, err := resolveUrl(resp.Request.URL, s.AttrOr("href", ""))
+		if err != nil {
+			return
+		}
+		if _, ok := known[href]; ok {
+			return
+		}
+		known[href] = struct{}{}
+
+		cands = append(cands, &Candidate{
+			Url: href,
+			Title: strings.TrimSpace(s.AttrOr("title", "")),
+			Type: typ,
+		})
+	})
+	if len(cands) < 1 {
+		return nil, nil, fmt.Errorf("'%s' is neither a feed nor a page which links a feed.", u)
+	}
+	return nil, cands, nil
+}
+
+// Validate fetches the url with the client of the job and checks that it is parsed as a feed.
+func Validate(msn *task.Mission, job *collector.Job, u string) (*Candidate, error) {
+	defer msn.Done()
+
+	resp, err := collector.HttpGet(msn, job, u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	feed, err := parseFeed(io.LimitReader(resp.Body, MAX_DISCOVER_SIZE), nil)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a feed: %s", u, err)
+	}
+	return &Candidate{Url: u, Title: feed.Title}, nil
+}
+
+func hasRel(val string, rel string) bool {
+	for _, r := range strings.Fields(val) {
+		if strings.EqualFold(r, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveUrl(base *url.URL, href string) (string, error) {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
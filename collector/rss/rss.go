@@ -1,31 +1,101 @@
 package rss
 
 import (
+	"io"
 	"time"
+	"bytes"
+	"strings"
+	"strconv"
 	"encoding/json"
 )
 
 import (
 	"github.com/l4go/task"
 	"github.com/mmcdole/gofeed"
+	"github.com/mmcdole/gofeed/rss"
+	"github.com/mmcdole/gofeed/atom"
+	ext "github.com/mmcdole/gofeed/extensions"
 )
 
 import (
-	"github.com/hinoshiba/gwyneth/slog"
 	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/collector"
 )
 
-func GetFeed(msn *task.Mission, logger *slog.Logger, src *model.Source, artcl_ch chan <- *model.Article) error {
+const (
+	NAME = "rss"
+)
+
+func init() {
+	collector.Register(NAME, New())
+}
+
+type Collector struct {}
+
+func New() *Collector {
+	return &Collector{}
+}
+
+func (self *Collector) Collect(msn *task.Mission, job *collector.Job) *model.Status {
 	defer msn.Done()
 
-	fp := gofeed.NewParser()
+	modified, err := GetFeed(msn.New(), job)
+	if err != nil {
+		return collector.MakeErrorStatus(err)
+	}
+	if !modified {
+		return collector.MakeSucceededStatus("Succeeded: not modified")
+	}
+	return collector.MakeSucceededStatus("Succeeded")
+}
+
+func GetFeed(msn *task.Mission, job *collector.Job) (bool, error) {
+	defer msn.Done()
+
+	url := job.Src.Value()
+	resp, err := collector.HttpGet(msn, job, url)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if collector.IsNotModified(resp) {
+		return false, nil
+	}
+
+	feed, err := parseFeed(resp.Body, job.State)
+	if err != nil {
+		return false, err
+	}
+	if job.State != nil {
+		if hub := collector.GetLinkHeader(resp, "hub"); hub != "" {
+			job.State.Hub = hub
+			if topic := collector.GetLinkHeader(resp, "self"); topic != "" {
+				job.State.Topic = topic
+			}
+		}
+		if job.State.Hub != "" && job.State.Topic == "" {
+			job.State.Topic = url
+		}
+	}
+
+	sendItems(msn, job, feed)
+	return true, nil
+}
+
+func (self *Collector) Receive(msn *task.Mission, job *collector.Job, r io.Reader) error {
+	defer msn.Done()
 
-	url := src.Value()
-	feed, err := fp.ParseURLWithContext(url, msn.AsContext())
+	feed, err := parseFeed(r, nil)
 	if err != nil {
 		return err
 	}
 
+	sendItems(msn, job, feed)
+	return nil
+}
+
+func sendItems(msn *task.Mission, job *collector.Job, feed *gofeed.Feed) {
 	now := time.Now()
 	for _, item := range feed.Items {
 		var pubdate time.Time = now
@@ -38,20 +108,206 @@ func GetFeed(msn *task.Mission, logger *slog.Logger, src *model.Source, artcl_ch
 
 		raw_j, err := json.Marshal(item)
 		if err != nil {
-			logger.Warn("convert errror: cannot convert to json str from item struct. : '%s', '%s'", item.Title, url)
+			job.Logger.Warn("convert errror: cannot convert to json str from item struct. : '%s', '%s'", item.Title, job.Src.Value())
 			continue
 		}
 
-		artcl := model.NewArticle(nil, src, item.Title, item.Description, item.Link, pubdate.Unix(), string(raw_j))
+		artcl := model.NewArticle(nil, job.Src, item.Title, item.Description, item.Link, pubdate.Unix(), string(raw_j))
+		job.Send(msn, artcl.WithMeta(getMeta(item)))
+	}
+}
 
-		go func(msn *task.Mission, artcl *model.Article) {
-			defer msn.Done()
+func getMeta(item *gofeed.Item) *model.ArticleMeta {
+	meta := &model.ArticleMeta{
+		Categories: item.Categories,
+		Guid: item.GUID,
+		Content: item.Content,
+	}
 
-			select {
-			case <- msn.RecvCancel():
-			case artcl_ch <- artcl:
+	if item.Author != nil {
+		meta.Author = item.Author.Name
+	}
+	if meta.Author == "" {
+		for _, author := range item.Authors {
+			if author != nil && author.Name != "" {
+				meta.Author = author.Name
+				break
 			}
-		}(msn.New(), artcl)
+		}
 	}
-	return nil
+
+	for _, enc := range item.Enclosures {
+		if enc == nil || enc.URL == "" {
+			continue
+		}
+		length, _ := strconv.ParseInt(strings.TrimSpace(enc.Length), 10, 64)
+		meta.Enclosures = append(meta.Enclosures, &model.Enclosure{
+			Url: enc.URL,
+			Type: enc.Type,
+			Length: length,
+		})
+	}
+
+	if item.Image != nil {
+		meta.Image = item.Image.URL
+	}
+	if meta.Image == "" {
+		for _, enc := range meta.Enclosures {
+			if strings.HasPrefix(enc.Type, "image/") {
+				meta.Image = enc.Url
+				break
+			}
+		}
+	}
+	return meta
+}
+
+func parseFeed(r io.Reader, st *model.SourceState) (*gofeed.Feed, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch gofeed.DetectFeedType(bytes.NewReader(b)) {
+	case gofeed.FeedTypeRSS:
+		rss_feed, err := (&rss.Parser{}).Parse(bytes.NewReader(b))
+		if err != nil {
+			return nil, collector.Permanent(err)
+		}
+		feed, err := (&gofeed.DefaultRSSTranslator{}).Translate(rss_feed)
+		if err != nil {
+			return nil, collector.Permanent(err)
+		}
+		if st != nil {
+			setSchedule(st, rss_feed)
+			st.Hub, st.Topic = getExtensionLinks(rss_feed.Extensions)
+		}
+		return feed, nil
+	case gofeed.FeedTypeAtom:
+		atom_feed, err := (&atom.Parser{}).Parse(bytes.NewReader(b))
+		if err != nil {
+			return nil, collector.Permanent(err)
+		}
+		feed, err := (&gofeed.DefaultAtomTranslator{}).Translate(atom_feed)
+		if err != nil {
+			return nil, collector.Permanent(err)
+		}
+		if st != nil {
+			st.TTL = getSyndicationPeriod(atom_feed.Extensions)
+			st.SkipHours = 0
+			st.SkipDays = 0
+
+			st.Hub, st.Topic = "", ""
+			for _, link := range atom_feed.Links {
+				switch link.Rel {
+				case "hub":
+					st.Hub = link.Href
+				case "self":
+					st.Topic = link.Href
+				}
+			}
+		}
+		return feed, nil
+	}
+
+	feed, err := gofeed.NewParser().Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, collector.Permanent(err)
+	}
+	if st != nil {
+		st.TTL = 0
+		st.SkipHours = 0
+		st.SkipDays = 0
+		st.Hub, st.Topic = "", ""
+	}
+	return feed, nil
+}
+
+func setSchedule(st *model.SourceState, feed *rss.Feed) {
+	st.TTL = 0
+	if ttl, err := strconv.Atoi(strings.TrimSpace(feed.TTL)); err == nil && ttl > 0 {
+		st.TTL = ttl * 60
+	}
+	if period := getSyndicationPeriod(feed.Extensions); period > st.TTL {
+		st.TTL = period
+	}
+
+	st.SkipHours = 0
+	for _, h := range feed.SkipHours {
+		hour, err := strconv.Atoi(strings.TrimSpace(h))
+		if err != nil {
+			continue
+		}
+		if hour == 24 {
+			hour = 0
+		}
+		st.SetSkipHour(hour)
+	}
+
+	st.SkipDays = 0
+	for _, d := range feed.SkipDays {
+		for wd := time.Sunday; wd <= time.Saturday; wd++ {
+			if strings.EqualFold(strings.TrimSpace(d), wd.String()) {
+				st.SetSkipDay(wd)
+			}
+		}
+	}
+}
+
+func getSyndicationPeriod(exts ext.Extensions) int {
+	sy, ok := exts["sy"]
+	if !ok {
+		return 0
+	}
+	_, has_period := sy["updatePeriod"]
+	_, has_freq := sy["updateFrequency"]
+	if !has_period && !has_freq {
+		return 0
+	}
+
+	var period int
+	switch strings.ToLower(strings.TrimSpace(getExtensionValue(sy, "updatePeriod"))) {
+	case "hourly":
+		period = 60 * 60
+	case "daily", "":
+		period = 24 * 60 * 60
+	case "weekly":
+		period = 7 * 24 * 60 * 60
+	case "monthly":
+		period = 30 * 24 * 60 * 60
+	case "yearly":
+		period = 365 * 24 * 60 * 60
+	default:
+		return 0
+	}
+
+	freq, err := strconv.Atoi(strings.TrimSpace(getExtensionValue(sy, "updateFrequency")))
+	if err != nil || freq < 1 {
+		freq = 1
+	}
+	return period / freq
+}
+
+func getExtensionLinks(exts ext.Extensions) (string, string) {
+	var hub string
+	var self string
+	for _, elems := range exts {
+		for _, link := range elems["link"] {
+			switch link.Attrs["rel"] {
+			case "hub":
+				hub = link.Attrs["href"]
+			case "self":
+				self = link.Attrs["href"]
+			}
+		}
+	}
+	return hub, self
+}
+
+func getExtensionValue(exts map[string][]ext.Extension, name string) string {
+	vals, ok := exts[name]
+	if !ok || len(vals) < 1 {
+		return ""
+	}
+	return vals[0].Value
 }
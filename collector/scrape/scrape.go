@@ -0,0 +1,177 @@
+package scrape
+
+import (
+	"fmt"
+	"time"
+	"strings"
+	"net/url"
+	"encoding/json"
+)
+
+import (
+	"github.com/l4go/task"
+	"github.com/PuerkitoBio/goquery"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/collector"
+)
+
+const (
+	NAME = "scrape"
+)
+
+func init() {
+	collector.Register(NAME, New())
+}
+
+// Config is the value of a scrape source.
+// The selectors of title, link, body and date are evaluated in each element which matches Item.
+type Config struct {
+	Url        string `json:"url"`
+	Item       string `json:"item"`
+	Title      string `json:"title"`
+	Link       string `json:"link"`
+	Body       string `json:"body"`
+	Date       string `json:"date"`
+	DateAttr   string `json:"date_attr"`
+	DateFormat string `json:"date_format"`
+}
+
+func ParseConfig(val string) (*Config, error) {
+	var cfg Config
+	if err := json.Unmarshal([]byte(val), &cfg); err != nil {
+		return nil, fmt.Errorf("the source value is not a json of the scrape config: %s", err)
+	}
+	if cfg.Url == "" {
+		return nil, fmt.Errorf("url is empty")
+	}
+	if cfg.Item == "" {
+		return nil, fmt.Errorf("item selector is empty")
+	}
+	return &cfg, nil
+}
+
+type Collector struct {}
+
+func New() *Collector {
+	return &Collector{}
+}
+
+func (self *Collector) Collect(msn *task.Mission, job *collector.Job) *model.Status {
+	defer msn.Done()
+
+	cfg, err := ParseConfig(job.Src.Value())
+	if err != nil {
+		return collector.MakeErrorStatus(collector.Permanent(err))
+	}
+
+	n, err := Scrape(msn.New(), job, cfg)
+	if err != nil {
+		return collector.MakeErrorStatus(err)
+	}
+	if n < 0 {
+		return collector.MakeSucceededStatus("Succeeded: not modified")
+	}
+	return collector.MakeSucceededStatus("Succeeded: %d items", n)
+}
+
+// Scrape sends the articles of the page, and returns the count of them. It returns -1 if the page is not modified.
+func Scrape(msn *task.Mission, job *collector.Job, cfg *Config) (int, error) {
+	defer msn.Done()
+
+	base, err := url.Parse(cfg.Url)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := collector.HttpGet(msn, job, cfg.Url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if collector.IsNotModified(resp) {
+		return -1, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	cnt := 0
+	doc.Find(cfg.Item).Each(func(_ int, item *goquery.Selection) {
+		title := strings.TrimSpace(find(item, cfg.Title).Text())
+		body := strings.TrimSpace(find(item, cfg.Body).Text())
+
+		link := ""
+		guid := ""
+		link_sel := item.Find("a")
+		if cfg.Link != "" {
+			link_sel = find(item, cfg.Link)
+		}
+		if href, ok := link_sel.Attr("href"); ok {
+			if u, err := base.Parse(strings.TrimSpace(href)); err == nil {
+				link = u.String()
+				if isAnchor(base, u) {
+					// the fragment is the only difference between the items in the page.
+					guid = link
+				}
+			}
+		}
+
+		pubdate := now
+		if cfg.Date != "" {
+			date_sel := find(item, cfg.Date)
+			val := date_sel.Text()
+			if cfg.DateAttr != "" {
+				val, _ = date_sel.Attr(cfg.DateAttr)
+			}
+			if t, err := collector.ParseTime(val, cfg.DateFormat); err == nil {
+				pubdate = t
+			} else {
+				job.Logger.Debug("cannot parse the date of '%s': %s", title, err)
+			}
+		}
+
+		if title == "" && body == "" {
+			return
+		}
+
+		raw, err := goquery.OuterHtml(item)
+		if err != nil {
+			job.Logger.Warn("convert errror: cannot get html of the item. : '%s', '%s'", title, cfg.Url)
+			return
+		}
+
+		artcl := model.NewArticle(nil, job.Src, title, body, link, pubdate.Unix(), raw)
+		job.Send(msn, artcl.WithMeta(&model.ArticleMeta{Guid: guid}))
+		cnt++
+	})
+	return cnt, nil
+}
+
+// isAnchor returns whether the link is a fragment of the page itself.
+func isAnchor(base *url.URL, u *url.URL) bool {
+	if u.Fragment == "" {
+		return false
+	}
+	page := *u
+	page.Fragment = ""
+	page.RawFragment = ""
+
+	b := *base
+	b.Fragment = ""
+	b.RawFragment = ""
+	return page.String() == b.String()
+}
+
+func find(item *goquery.Selection, selector string) *goquery.Selection {
+	if selector == "" {
+		return item
+	}
+	return item.Find(selector).First()
+}
@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+	"strconv"
+	"strings"
+)
+
+var (
+	TIME_LAYOUTS = []string{
+		time.RFC3339Nano,
+		time.RFC3339,
+		time.RFC1123Z,
+		time.RFC1123,
+		time.RFC850,
+		time.RFC822Z,
+		time.RFC822,
+		time.ANSIC,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05 -0700",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+		"2006/01/02 15:04:05",
+		"2006/01/02 15:04",
+		"2006/01/02",
+		"January 2, 2006",
+		"Jan 2, 2006",
+		"2 January 2006",
+		"2 Jan 2006",
+	}
+)
+
+// ParseTime parses a time with the layout. Without the layout, a unixtime and the well-known layouts are tried.
+func ParseTime(val string, layout string) (time.Time, error) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return time.Time{}, fmt.Errorf("time is empty")
+	}
+	if layout != "" {
+		return time.Parse(layout, val)
+	}
+
+	if utime, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Unix(utime, 0), nil
+	}
+	for _, l := range TIME_LAYOUTS {
+		if t, err := time.Parse(l, val); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown time format: '%s'", val)
+}
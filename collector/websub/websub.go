@@ -0,0 +1,110 @@
+package websub
+
+import (
+	"fmt"
+	"hash"
+	"strings"
+	"strconv"
+	"net/url"
+	"net/http"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+)
+
+import (
+	"github.com/l4go/task"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/collector"
+)
+
+const (
+	MODE_SUBSCRIBE   = "subscribe"
+	MODE_UNSUBSCRIBE = "unsubscribe"
+	MODE_DENIED      = "denied"
+)
+
+var (
+	hashes = map[string]func() hash.Hash{
+		"sha1": sha1.New,
+		"sha256": sha256.New,
+		"sha384": sha512.New384,
+		"sha512": sha512.New,
+	}
+)
+
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func Subscribe(msn *task.Mission, hub string, topic string, callback string, secret string, lease int) error {
+	defer msn.Done()
+
+	vals := url.Values{}
+	vals.Set("hub.mode", MODE_SUBSCRIBE)
+	vals.Set("hub.topic", topic)
+	vals.Set("hub.callback", callback)
+	vals.Set("hub.secret", secret)
+	if lease > 0 {
+		vals.Set("hub.lease_seconds", strconv.Itoa(lease))
+	}
+	return request(msn, hub, vals)
+}
+
+func Unsubscribe(msn *task.Mission, hub string, topic string, callback string) error {
+	defer msn.Done()
+
+	vals := url.Values{}
+	vals.Set("hub.mode", MODE_UNSUBSCRIBE)
+	vals.Set("hub.topic", topic)
+	vals.Set("hub.callback", callback)
+	return request(msn, hub, vals)
+}
+
+func request(msn *task.Mission, hub string, vals url.Values) error {
+	req, err := http.NewRequestWithContext(msn.AsContext(), http.MethodPost, hub, strings.NewReader(vals.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", collector.USER_AGENT)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the hub refused the request: %s", resp.Status)
+	}
+	return nil
+}
+
+func VerifySignature(secret string, sig string, body []byte) bool {
+	method, val, ok := strings.Cut(sig, "=")
+	if !ok {
+		return false
+	}
+	f, ok := hashes[strings.ToLower(method)]
+	if !ok {
+		return false
+	}
+	expect, err := hex.DecodeString(val)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(f, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expect)
+}
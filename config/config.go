@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"fmt"
+	"time"
 	"log/slog"
 	"path/filepath"
 )
@@ -43,11 +44,13 @@ func Load(path string) (*Config, error) {
 }
 
 type Config struct {
-	Database *Database `yaml:"database"`
-	Http     *Http     `yaml:"http"`
-	Feed     *Feed     `yaml:"feed"`
-	Log      *Log      `yaml:"log"`
-	Action   *Action   `yaml:"action"`
+	Database  *Database  `yaml:"database"`
+	Http      *Http      `yaml:"http"`
+	Feed      *Feed      `yaml:"feed"`
+	Log       *Log       `yaml:"log"`
+	Action    *Action    `yaml:"action"`
+	Collector *Collector `yaml:"collector"`
+	Smtp      *Smtp      `yaml:"smtp"`
 }
 
 func (self *Config) check() error {
@@ -66,9 +69,173 @@ func (self *Config) check() error {
 	if err := self.Action.check(); err != nil {
 		return err
 	}
+	if self.Collector == nil {
+		self.Collector = &Collector{}
+	}
+	if err := self.Collector.check(); err != nil {
+		return err
+	}
+	if self.Smtp != nil {
+		if err := self.Smtp.check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	DEFAULT_COLLECTOR_INTERVAL = 60 * 5
+	MIN_COLLECTOR_INTERVAL = 60
+
+	DEFAULT_MAX_FAILURES = 10
+	DEFAULT_MAX_PERMANENT_FAILURES = 3
+
+	DEFAULT_HISTORY_RETENTION = 30
+
+	DEFAULT_COLLECTOR_CONCURRENCY = 10
+	DEFAULT_HOST_CONCURRENCY = 2
+	DEFAULT_HOST_DELAY = 1000
+
+	DEFAULT_COMMAND_TIMEOUT = 60 * 10
+
+	DEFAULT_WEBSUB_LEASE = 60 * 60 * 24 * 7
+	DEFAULT_WEBSUB_MAX_SIZE = 16 * 1024 * 1024
+)
+
+type Collector struct {
+	Interval             int     `yaml:"interval"`
+	MaxFailures          int     `yaml:"max_failures"`
+	MaxPermanentFailures int     `yaml:"max_permanent_failures"`
+	HistoryRetention     int     `yaml:"history_retention"` // days to keep the fetch logs. a negative value keeps them forever.
+	Secrets              string  `yaml:"secrets"`           // the yaml file of the secrets which the sources refer to.
+	Concurrency          int     `yaml:"concurrency"`       // the number of the collections at the same time.
+	HostConcurrency      int     `yaml:"host_concurrency"`  // the number of the requests to a host at the same time. a negative value is no limit.
+	HostDelay            int     `yaml:"host_delay"`        // milliseconds between the requests to a host. a negative value is no delay.
+	Robots               bool    `yaml:"robots"`            // honors the robots.txt of the hosts.
+	CommandTimeout       int     `yaml:"command_timeout"`   // seconds to kill the command of a user created type. a negative value is no limit.
+	WebSub               *WebSub `yaml:"websub"`
+}
+
+func (self *Collector) check() error {
+	if self.Interval == 0 {
+		self.Interval = DEFAULT_COLLECTOR_INTERVAL
+	}
+	if self.Interval < MIN_COLLECTOR_INTERVAL {
+		return fmt.Errorf("Collector.Interval is too short: %d < %d", self.Interval, MIN_COLLECTOR_INTERVAL)
+	}
+	if self.MaxFailures == 0 {
+		self.MaxFailures = DEFAULT_MAX_FAILURES
+	}
+	if self.MaxPermanentFailures == 0 {
+		self.MaxPermanentFailures = DEFAULT_MAX_PERMANENT_FAILURES
+	}
+	if self.Concurrency == 0 {
+		self.Concurrency = DEFAULT_COLLECTOR_CONCURRENCY
+	}
+	if self.Concurrency < 0 {
+		return fmt.Errorf("Collector.Concurrency is negative: %d", self.Concurrency)
+	}
+	if self.HostConcurrency == 0 {
+		self.HostConcurrency = DEFAULT_HOST_CONCURRENCY
+	}
+	if self.HostDelay == 0 {
+		self.HostDelay = DEFAULT_HOST_DELAY
+	}
+	if self.HistoryRetention == 0 {
+		self.HistoryRetention = DEFAULT_HISTORY_RETENTION
+	}
+	if self.CommandTimeout == 0 {
+		self.CommandTimeout = DEFAULT_COMMAND_TIMEOUT
+	}
+	if self.WebSub == nil {
+		self.WebSub = &WebSub{}
+	}
+	if err := self.WebSub.check(); err != nil {
+		return err
+	}
+	return nil
+}
+
+type WebSub struct {
+	Callback string `yaml:"callback"`
+	Lease    int    `yaml:"lease"`
+	MaxSize  int64  `yaml:"max_size"` // bytes of the body of a pushed content.
+}
+
+// IsQuarantine returns true if a source should be paused after the consecutive failures.
+// A negative limit never pauses a source.
+func (self *Collector) IsQuarantine(failures int, permanent bool) bool {
+	limit := self.MaxFailures
+	if permanent {
+		limit = self.MaxPermanentFailures
+	}
+	return limit > 0 && failures >= limit
+}
+
+// GetHostDelay returns the delay between the requests to a host. It is 0 if it is disabled.
+func (self *Collector) GetHostDelay() time.Duration {
+	if self.HostDelay < 0 {
+		return 0
+	}
+	return time.Duration(self.HostDelay) * time.Millisecond
+}
+
+// GetCommandTimeout returns the timeout of the command of a user created type. It is 0 if it is disabled.
+func (self *Collector) GetCommandTimeout() time.Duration {
+	if self.CommandTimeout < 0 {
+		return 0
+	}
+	return time.Duration(self.CommandTimeout) * time.Second
+}
+
+func (self *WebSub) check() error {
+	if self.Lease == 0 {
+		self.Lease = DEFAULT_WEBSUB_LEASE
+	}
+	if self.Lease < 0 {
+		return fmt.Errorf("WebSub.Lease is negative: %d", self.Lease)
+	}
+	if self.MaxSize == 0 {
+		self.MaxSize = DEFAULT_WEBSUB_MAX_SIZE
+	}
+	if self.MaxSize < 0 {
+		return fmt.Errorf("WebSub.MaxSize is negative: %d", self.MaxSize)
+	}
 	return nil
 }
 
+func (self *WebSub) IsEnabled() bool {
+	return self.Callback != ""
+}
+
+const (
+	DEFAULT_SMTP_MAX_SIZE = 10 * 1024 * 1024
+)
+
+type Smtp struct {
+	Host    string `yaml:"host"`
+	Port    int    `yaml:"port"`
+	Domain  string `yaml:"domain"`
+	MaxSize int    `yaml:"max_size"`
+}
+
+func (self *Smtp) check() error {
+	if 0 >= self.Port || self.Port > 65535 {
+		return fmt.Errorf("smtp port number out of range.")
+	}
+	if self.MaxSize == 0 {
+		self.MaxSize = DEFAULT_SMTP_MAX_SIZE
+	}
+	if self.MaxSize < 0 {
+		return fmt.Errorf("Smtp.MaxSize is negative: %d", self.MaxSize)
+	}
+	return nil
+}
+
+func (self *Smtp) GetAddr() string {
+	return fmt.Sprintf("%s:%d", self.Host, self.Port)
+}
+
 type Action struct {
 	QueueDir string `yaml:"queue_dir"`
 }
@@ -130,16 +297,31 @@ func (self *Database) check() error {
 	return nil
 }
 
+const (
+	DEFAULT_HOOK_MAX_SIZE = 1024 * 1024
+)
+
 type Http struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
-	Root string `yaml:"app_root"`
+	Host        string `yaml:"host"`
+	Port        int    `yaml:"port"`
+	Root        string `yaml:"app_root"`
+	HookMaxSize int64  `yaml:"hook_max_size"` // bytes of the body of a webhook.
+
+	// SourceTypeApi publishes the api which registers a command as a source type. The api has no authentication,
+	// so it should be enabled only if the api is not reachable from the others.
+	SourceTypeApi bool `yaml:"source_type_api"`
 }
 
 func (self *Http) check() error {
 	if 0 >= self.Port || self.Port > 65535 {
 		return fmt.Errorf("http port number out of range.")
 	}
+	if self.HookMaxSize == 0 {
+		self.HookMaxSize = DEFAULT_HOOK_MAX_SIZE
+	}
+	if self.HookMaxSize < 0 {
+		return fmt.Errorf("Http.HookMaxSize is negative: %d", self.HookMaxSize)
+	}
 	return nil
 }
 
@@ -4,8 +4,6 @@ import "time"
 
 const (
 	VERSION = "v0.0.1"
-
-	COLLECTOR_RSS_POOL_SIZE = 100
 )
 
 var (
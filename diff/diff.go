@@ -0,0 +1,196 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	CONTEXT_LINES = 3
+
+	// MAX_CELLS limits the table of the longest common subsequence. Larger texts are diffed as a whole.
+	MAX_CELLS = 4 * 1024 * 1024
+)
+
+type op int
+
+const (
+	op_equal op = iota
+	op_delete
+	op_insert
+)
+
+type line struct {
+	op   op
+	text string
+	a    int // the line number of the old text. (0-origin)
+	b    int // the line number of the new text. (0-origin)
+}
+
+// Unified returns the unified diff of the lines from a to b. It is empty if they are same.
+func Unified(a string, b string) string {
+	if a == b {
+		return ""
+	}
+
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	for _, hunk := range makeHunks(lines) {
+		a_start, a_len, b_start, b_len := hunkRange(hunk)
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", a_start, a_len, b_start, b_len)
+		for _, l := range hunk {
+			switch l.op {
+			case op_equal:
+				sb.WriteString(" ")
+			case op_delete:
+				sb.WriteString("-")
+			case op_insert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(l.text)
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
+
+func diffLines(a []string, b []string) []*line {
+	// the common prefix and suffix are trimmed to keep the table small.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a) - pre && suf < len(b) - pre && a[len(a) - 1 - suf] == b[len(b) - 1 - suf] {
+		suf++
+	}
+
+	lines := []*line{}
+	for i := 0; i < pre; i++ {
+		lines = append(lines, &line{op: op_equal, text: a[i], a: i, b: i})
+	}
+
+	m_a := a[pre:len(a) - suf]
+	m_b := b[pre:len(b) - suf]
+	if len(m_a) * len(m_b) > MAX_CELLS {
+		for i, s := range m_a {
+			lines = append(lines, &line{op: op_delete, text: s, a: pre + i, b: pre})
+		}
+		for i, s := range m_b {
+			lines = append(lines, &line{op: op_insert, text: s, a: pre + len(m_a), b: pre + i})
+		}
+	} else {
+		lines = append(lines, diffLCS(m_a, m_b, pre)...)
+	}
+
+	for i := 0; i < suf; i++ {
+		lines = append(lines, &line{op: op_equal, text: a[len(a) - suf + i],
+			a: len(a) - suf + i, b: len(b) - suf + i})
+	}
+	return lines
+}
+
+func diffLCS(a []string, b []string, offset int) []*line {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a) + 1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b) + 1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i + 1][j + 1] + 1
+			} else if lcs[i + 1][j] >= lcs[i][j + 1] {
+				lcs[i][j] = lcs[i + 1][j]
+			} else {
+				lcs[i][j] = lcs[i][j + 1]
+			}
+		}
+	}
+
+	lines := []*line{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, &line{op: op_equal, text: a[i], a: offset + i, b: offset + j})
+			i++
+			j++
+		case j >= len(b) || (i < len(a) && lcs[i + 1][j] >= lcs[i][j + 1]):
+			lines = append(lines, &line{op: op_delete, text: a[i], a: offset + i, b: offset + j})
+			i++
+		default:
+			lines = append(lines, &line{op: op_insert, text: b[j], a: offset + i, b: offset + j})
+			j++
+		}
+	}
+	return lines
+}
+
+// makeHunks groups the changed lines with CONTEXT_LINES lines around them.
+func makeHunks(lines []*line) [][]*line {
+	hunks := [][]*line{}
+
+	start, end := -1, -1
+	for i, l := range lines {
+		if l.op == op_equal {
+			continue
+		}
+
+		from := i - CONTEXT_LINES
+		if from < 0 {
+			from = 0
+		}
+		to := i + CONTEXT_LINES + 1
+		if to > len(lines) {
+			to = len(lines)
+		}
+
+		if start >= 0 && from > end {
+			hunks = append(hunks, lines[start:end])
+			start = -1
+		}
+		if start < 0 {
+			start = from
+		}
+		end = to
+	}
+	if start >= 0 {
+		hunks = append(hunks, lines[start:end])
+	}
+	return hunks
+}
+
+func hunkRange(hunk []*line) (int, int, int, int) {
+	a_len, b_len := 0, 0
+	for _, l := range hunk {
+		switch l.op {
+		case op_equal:
+			a_len++
+			b_len++
+		case op_delete:
+			a_len++
+		case op_insert:
+			b_len++
+		}
+	}
+
+	// the line numbers are 1-origin, and it is the line before the hunk if the hunk is empty.
+	a_start := hunk[0].a + 1
+	if a_len == 0 {
+		a_start--
+	}
+	b_start := hunk[0].b + 1
+	if b_len == 0 {
+		b_start--
+	}
+	return a_start, a_len, b_start, b_len
+}
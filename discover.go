@@ -0,0 +1,111 @@
+package gwyneth
+
+import (
+	"fmt"
+	"time"
+)
+
+import (
+	"github.com/l4go/task"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/collector"
+	"github.com/hinoshiba/gwyneth/collector/rss"
+)
+
+const (
+	DISCOVER_TIMEOUT = 30 * time.Second
+)
+
+// NotFeedError is returned when the value of a rss source is a page which links feeds.
+type NotFeedError struct {
+	Url        string
+	Candidates []*rss.Candidate
+}
+
+func (self *NotFeedError) Error() string {
+	return fmt.Sprintf("'%s' is not a feed, it links %d feeds.", self.Url, len(self.Candidates))
+}
+
+func (self *Gwyneth) isRssSource(src_type_id *model.Id) (bool, error) {
+	st, err := self.tv.GetSourceType(src_type_id)
+	if err != nil {
+		return false, err
+	}
+	return st.Name() == rss.NAME && !st.IsUserCreate(), nil
+}
+
+// discoverFeed returns the feed of the url. If the url is a page which links
+// feeds, the first one is picked with auto_pick, otherwise NotFeedError is returned.
+// The requests use the http option of the source, it is nil for a new source.
+func (self *Gwyneth) discoverFeed(u string, auto_pick bool, opt *model.HttpOption) (*rss.Candidate, error) {
+	job, err := self.newDiscoverJob(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	var feed *rss.Candidate
+	err = self.runDiscover(func(msn *task.Mission) error {
+		self_feed, cands, err := rss.Discover(msn.New(), job, u)
+		if err != nil {
+			return err
+		}
+		if self_feed != nil {
+			feed = self_feed
+			return nil
+		}
+		if !auto_pick {
+			return &NotFeedError{Url: u, Candidates: cands}
+		}
+
+		valid, err := rss.Validate(msn.New(), job, cands[0].Url)
+		if err != nil {
+			return err
+		}
+		feed = cands[0]
+		if feed.Title == "" {
+			feed.Title = valid.Title
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// validateFeed checks that the url is a feed with the http option of the source.
+func (self *Gwyneth) validateFeed(u string, opt *model.HttpOption) error {
+	job, err := self.newDiscoverJob(opt)
+	if err != nil {
+		return err
+	}
+	return self.runDiscover(func(msn *task.Mission) error {
+		_, err := rss.Validate(msn.New(), job, u)
+		return err
+	})
+}
+
+func (self *Gwyneth) newDiscoverJob(opt *model.HttpOption) (*collector.Job, error) {
+	client, err := self.newHttpClient(opt)
+	if err != nil {
+		return nil, err
+	}
+	return &collector.Job{
+		Logger: self.lm.GetCollectorsLogger(),
+		Http: client,
+	}, nil
+}
+
+// runDiscover cancels fn at DISCOVER_TIMEOUT, because it runs in the handler of the request.
+func (self *Gwyneth) runDiscover(fn func(*task.Mission) error) error {
+	msn := self.msn.New()
+	defer msn.Done()
+
+	tm := time.AfterFunc(DISCOVER_TIMEOUT, msn.Cancel)
+	defer tm.Stop()
+
+	return fn(msn)
+}
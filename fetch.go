@@ -0,0 +1,151 @@
+package gwyneth
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+)
+
+const (
+	FETCH_JOB_RETENTION = 1 * time.Hour
+)
+
+// FetchSource requests a collection of the source now. The collection is run
+// by the collector's pool, and its result is set to the returned job.
+func (self *Gwyneth) FetchSource(id *model.Id) (*model.FetchJob, error) {
+	src, err := self.tv.GetSource(id)
+	if err != nil {
+		return nil, err
+	}
+	if src.IsPause() {
+		return nil, fmt.Errorf("the source is paused.")
+	}
+
+	job := self.fetch_mgr.Add(id, time.Now())
+	if !self.sched.Trigger(id, time.Now()) {
+		self.fetch_mgr.Remove(job.Id)
+		return nil, fmt.Errorf("the source is not collected.")
+	}
+	self.fetch_req.Notice()
+	return job, nil
+}
+
+func (self *Gwyneth) GetFetchJob(id *model.Id) (*model.FetchJob, error) {
+	return self.fetch_mgr.Get(id)
+}
+
+type fetchManager struct {
+	jobs map[string]*model.FetchJob
+
+	mtx *sync.Mutex
+}
+
+func newFetchManager() *fetchManager {
+	return &fetchManager{
+		jobs: make(map[string]*model.FetchJob),
+		mtx: new(sync.Mutex),
+	}
+}
+
+func (self *fetchManager) Add(src_id *model.Id, now time.Time) *model.FetchJob {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	ret := *self.add(src_id, now)
+	return &ret
+}
+
+func (self *fetchManager) add(src_id *model.Id, now time.Time) *model.FetchJob {
+	for id, job := range self.jobs {
+		if now.Sub(job.Requested) > FETCH_JOB_RETENTION {
+			delete(self.jobs, id)
+		}
+	}
+
+	job := &model.FetchJob{
+		Id: model.NewId(nil),
+		SrcId: src_id,
+		Requested: now,
+	}
+	self.jobs[job.Id.String()] = job
+	return job
+}
+
+// Begin adds a started job which is run out of the collector's pool, e.g. a backfill.
+func (self *fetchManager) Begin(src_id *model.Id, now time.Time) *model.FetchJob {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	job := self.add(src_id, now)
+	job.Started = now
+
+	ret := *job
+	return &ret
+}
+
+// End sets the result to the job which is added by Begin.
+func (self *fetchManager) End(id *model.Id, st *model.Status) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	job, ok := self.jobs[id.String()]
+	if !ok {
+		return
+	}
+	job.Status = st
+}
+
+func (self *fetchManager) Remove(id *model.Id) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	delete(self.jobs, id.String())
+}
+
+func (self *fetchManager) Get(id *model.Id) (*model.FetchJob, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	job, ok := self.jobs[id.String()]
+	if !ok {
+		return nil, fmt.Errorf("cannot find the fetch job.")
+	}
+	ret := *job
+	return &ret, nil
+}
+
+// Start marks the jobs of the source which are requested before the collection.
+func (self *fetchManager) Start(src_id *model.Id, started time.Time) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	for _, job := range self.jobs {
+		if job.SrcId.String() != src_id.String() || !job.Started.IsZero() {
+			continue
+		}
+		if job.Requested.After(started) {
+			continue
+		}
+		job.Started = started
+	}
+}
+
+// Finish sets the result to the jobs which are started by the collection.
+func (self *fetchManager) Finish(src_id *model.Id, started time.Time, st *model.Status) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	for _, job := range self.jobs {
+		if job.SrcId.String() != src_id.String() || job.Status != nil {
+			continue
+		}
+		if !job.Started.Equal(started) {
+			continue
+		}
+		job.Status = st
+	}
+}
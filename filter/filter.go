@@ -19,10 +19,12 @@ type Filter struct {
 	val_body       string
 	is_regex_body  bool
 
+	on_update      bool
+
 	action       *Action
 }
 
-func NewFilter(id *model.Id, val_title string, is_regex_title bool, val_body string, is_regex_body bool, action *Action) *Filter {
+func NewFilter(id *model.Id, val_title string, is_regex_title bool, val_body string, is_regex_body bool, on_update bool, action *Action) *Filter {
 	return &Filter{
 		id: id,
 
@@ -32,6 +34,8 @@ func NewFilter(id *model.Id, val_title string, is_regex_title bool, val_body str
 		val_body: val_body,
 		is_regex_body: is_regex_body,
 
+		on_update: on_update,
+
 		action: action,
 	}
 }
@@ -56,6 +60,11 @@ func (self *Filter) IsRegexBody() bool {
 	return self.is_regex_body
 }
 
+// OnUpdate is true if the filter is evaluated again when the title or the body of an article is changed by the source.
+func (self *Filter) OnUpdate() bool {
+	return self.on_update
+}
+
 func (self *Filter) Action() *Action {
 	return self.action
 }
@@ -72,14 +81,24 @@ func (self *Filter) IsMatch(artlc *model.Article) bool {
 		}
 	}
 
-	if self.is_regex_body {
-		match, _ := regexp.MatchString(self.val_body, artlc.Body())
-		if match {
-			return true
+	// the body matches the full text and the meta too, because the body of a feed is often a teaser of it.
+	bodies := []string{artlc.Body()}
+	meta := artlc.Meta()
+	for _, s := range append([]string{artlc.FullText(), meta.Content, meta.Author}, meta.Categories...) {
+		if s != "" {
+			bodies = append(bodies, s)
 		}
-	} else {
-		if strings.Contains(artlc.Body(), self.val_body) {
-			return true
+	}
+	for _, body := range bodies {
+		if self.is_regex_body {
+			match, _ := regexp.MatchString(self.val_body, body)
+			if match {
+				return true
+			}
+		} else {
+			if strings.Contains(body, self.val_body) {
+				return true
+			}
 		}
 	}
 
@@ -97,6 +116,7 @@ func (self *Filter) ConvertExternal() *external.Filter {
 			Value: self.val_body,
 			IsRegex: self.is_regex_body,
 		},
+		OnUpdate: self.on_update,
 		Action: self.action.ConvertExternal(),
 	}
 }
@@ -17,37 +17,46 @@ import (
 import (
 	"github.com/hinoshiba/gwyneth/slog"
 	"github.com/hinoshiba/gwyneth/config"
+	"github.com/hinoshiba/gwyneth/secret"
 	"github.com/hinoshiba/gwyneth/tv"
 	"github.com/hinoshiba/gwyneth/model"
 	"github.com/hinoshiba/gwyneth/filter"
 
 	"github.com/hinoshiba/gwyneth/tv/errors"
 
-	"github.com/hinoshiba/gwyneth/collector/rss"
+	"github.com/hinoshiba/gwyneth/collector"
+	"github.com/hinoshiba/gwyneth/collector/fulltext"
 )
 
 const (
-	COLLECTOR_RSS_POOL_SIZE = 10
+	FULLTEXT_POOL_SIZE  = 4
+	FULLTEXT_QUEUE_SIZE = 1024
+	FULLTEXT_TIMEOUT    = 60 * time.Second
 )
 
 type Gwyneth struct {
 	tv  *tv.TimeVortex
 	msn *task.Mission
 
-	cfg *config.Config
+	cfg     *config.Config
+	secrets *secret.Store
 
 	lm         *slog.LogManager
-	status_mgr *statusManager
+	fetch_mgr  *fetchManager
+	sched      *scheduler
 
 	new_src       *noticer
 	filter_cond   *noticer
+	fetch_req     *noticer
 
-	artcl_ch     chan *model.Article
+	artcl_ch     chan *record
 	do_filter_ch chan *model.Article
+	fulltext_ch  chan *fullTextRequest
 
 	default_source_type map[string]struct{}
 
 	action_mgr_idx *actionManagerIndex
+	unsubs         *unsubscriptions
 }
 
 func New(msn *task.Mission, lm *slog.LogManager, cfg *config.Config) (*Gwyneth, error) {
@@ -55,22 +64,29 @@ func New(msn *task.Mission, lm *slog.LogManager, cfg *config.Config) (*Gwyneth,
 	if err != nil {
 		return nil, err
 	}
+	collector.SetPoliteness(cfg.Collector.HostConcurrency, cfg.Collector.GetHostDelay(), cfg.Collector.Robots)
+	collector.SetCommandTimeout(cfg.Collector.GetCommandTimeout())
 	self := &Gwyneth {
 		tv: t,
 		msn: msn,
 
 		cfg: cfg,
+		secrets: secret.New(cfg.Collector.Secrets),
 
 		lm: lm,
-		status_mgr: newStatusManager(),
+		fetch_mgr: newFetchManager(),
+		sched: newScheduler(cfg.Collector.Interval),
 
-		artcl_ch: make(chan *model.Article),
+		artcl_ch: make(chan *record),
 		do_filter_ch: make(chan *model.Article),
+		fulltext_ch: make(chan *fullTextRequest, FULLTEXT_QUEUE_SIZE),
 
 		new_src:       newNoticer(msn.NewCancel()),
 		filter_cond: newNoticer(msn.NewCancel()),
+		fetch_req: newNoticer(msn.NewCancel()),
 
 		action_mgr_idx: newActionManagerIndex(),
+		unsubs: newUnsubscriptions(),
 	}
 
 	if err := self.init(); err != nil {
@@ -99,6 +115,9 @@ func (self *Gwyneth) init() error {
 
 	go self.run_core(self.msn.New())
 	go self.run_article_recoder(self.msn.New())
+	go self.run_fulltext_extractor(self.msn.New())
+	go self.run_websub(self.msn.New())
+	go self.run_history_cleaner(self.msn.New())
 	self.run_action_managers()
 
 	self.new_src.Notice()
@@ -124,8 +143,8 @@ func (self *Gwyneth) run_core(msn *task.Mission) {
 			go func(msn_clctr *task.Mission){
 				defer msn_clctr.Done()
 
-				if err := self.run_rss_collector(msn_clctr.New()); err != nil {
-					slog.Error("failed: wakeup rss collector: %s", err)
+				if err := self.run_collector(msn_clctr.New()); err != nil {
+					slog.Error("failed: wakeup collector: %s", err)
 				}
 			}(msn_clctr)
 		case <- self.filter_cond.Recv():
@@ -154,13 +173,46 @@ func (self *Gwyneth) run_article_recoder(msn *task.Mission) error {
 		select {
 		case <- msn.RecvCancel():
 			return nil
-		case artcl := <- self.artcl_ch:
-			added_artcl, err := self.addArticle(artcl.Title(), artcl.Body(), artcl.Link(), artcl.Unixtime(), artcl.Raw(), artcl.Src().Id())
+		case rec := <- self.artcl_ch:
+			artcl := rec.artcl
+			added_artcl, err := self.addArticle(artcl.Title(), artcl.Body(), artcl.Link(), artcl.Unixtime(), artcl.Raw(), artcl.Meta(), artcl.Src().Id())
+			rec.done(err)
 			if err != nil {
 				if err == errors.ERR_ALREADY_EXIST_ARTICLE {
 					continue
 				}
-				slog.Warn("failed: addArticle: %s", err)
+				if err != errors.ERR_UPDATED_ARTICLE {
+					slog.Warn("failed: addArticle: %s", err)
+					continue
+				}
+
+				if rec.quiet {
+					continue
+				}
+				// an updated article is passed to the filters which are evaluated on update.
+				select {
+				case <- msn.RecvCancel():
+					return nil
+				case self.do_filter_ch <- added_artcl:
+				}
+				continue
+			}
+
+			opt, err := self.tv.GetSourceOption(added_artcl.Src().Id())
+			if err != nil {
+				slog.Warn("failed: cannot get the option of '%s': %s", added_artcl.Src().Id(), err)
+				opt = &model.SourceOption{}
+			}
+			if opt.FullText {
+				// the full text is filled in background, so a slow page does not stop the recorder.
+				select {
+				case self.fulltext_ch <- &fullTextRequest{artcl: added_artcl, opt: opt, quiet: rec.quiet}:
+					continue
+				default:
+					slog.Warn("failed: the queue of the full text is full, '%s' is passed without it", added_artcl.Link())
+				}
+			}
+			if rec.quiet {
 				continue
 			}
 
@@ -173,6 +225,127 @@ func (self *Gwyneth) run_article_recoder(msn *task.Mission) error {
 	}
 }
 
+// runJob runs fn with the job whose articles are passed to the recorder,
+// and returns the count of the new articles after all of them are recorded.
+// It returns an error if some of the articles cannot be recorded.
+// With quiet, the articles are not passed to the filters, so no action is fired.
+func (self *Gwyneth) runJob(msn *task.Mission, job *collector.Job, quiet bool, fn func()) (int, error) {
+	defer msn.Done()
+
+	artcl_ch := make(chan *model.Article)
+	counted := make(chan *recorded)
+	go self.recordArticles(msn.New(), artcl_ch, quiet, counted)
+
+	job.ArticleCh = artcl_ch
+	fn()
+
+	job.Wait()
+	close(artcl_ch)
+
+	ret := <- counted
+	// the articles which are dropped by the cancel are not recorded either.
+	ret.failed += job.Dropped()
+	if ret.failed > 0 {
+		return ret.added, fmt.Errorf("cannot record %d articles", ret.failed)
+	}
+	job.Commit()
+	return ret.added, nil
+}
+
+type recorded struct {
+	added  int
+	failed int
+}
+
+func (self *Gwyneth) recordArticles(msn *task.Mission, artcl_ch <- chan *model.Article, quiet bool, counted chan <- *recorded) {
+	defer msn.Done()
+
+	ret := &recorded{}
+	for artcl := range artcl_ch {
+		result := make(chan error, 1)
+		select {
+		case <- msn.RecvCancel():
+			ret.failed++
+			continue
+		case self.artcl_ch <- &record{artcl: artcl, result: result, quiet: quiet}:
+		}
+
+		switch err := <- result; err {
+		case nil:
+			ret.added++
+		case errors.ERR_ALREADY_EXIST_ARTICLE, errors.ERR_UPDATED_ARTICLE:
+		default:
+			ret.failed++
+		}
+	}
+	counted <- ret
+}
+
+type fullTextRequest struct {
+	artcl *model.Article
+	opt   *model.SourceOption
+	quiet bool
+}
+
+func (self *Gwyneth) run_fulltext_extractor(msn *task.Mission) {
+	defer msn.Done()
+
+	p := task.NewPool(msn.New(), FULLTEXT_POOL_SIZE)
+	defer p.Close()
+
+	for {
+		select {
+		case <- msn.RecvCancel():
+			return
+		case req := <- self.fulltext_ch:
+			p.Do(self.extractFullText, msn.New(), req.artcl, req.opt, req.quiet)
+		}
+	}
+}
+
+// extractFullText stores the main text of the linked page of the article, and passes it to the filters unless quiet.
+// The page is given up at FULLTEXT_TIMEOUT.
+func (self *Gwyneth) extractFullText(msn *task.Mission, args ...any) {
+	defer msn.Done()
+
+	artcl := args[0].(*model.Article)
+	opt := args[1].(*model.SourceOption)
+	quiet := args[2].(bool)
+	logger := self.lm.GetCollectorsLogger()
+
+	job := &collector.Job{
+		Logger: logger,
+		Src: artcl.Src(),
+	}
+	client, err := self.newHttpClient(opt.Http)
+	if err != nil {
+		logger.Warn("cannot extract the full text of '%s': %s", artcl.Link(), err)
+	} else {
+		job.Http = client
+
+		f_msn := msn.New()
+		tm := time.AfterFunc(FULLTEXT_TIMEOUT, f_msn.Cancel)
+		text, err := fulltext.Fetch(f_msn.New(), job, artcl.Link())
+		tm.Stop()
+		f_msn.Done()
+		if err != nil {
+			logger.Warn("cannot extract the full text of '%s': %s", artcl.Link(), err)
+		} else if err := self.tv.UpdateArticleFullText(artcl.Id(), text); err != nil {
+			logger.Warn("cannot save the full text of '%s': %s", artcl.Link(), err)
+		} else {
+			artcl = artcl.WithFullText(text)
+		}
+	}
+	if quiet {
+		return
+	}
+
+	select {
+	case <- msn.RecvCancel():
+	case self.do_filter_ch <- artcl:
+	}
+}
+
 func (self *Gwyneth) run_filter_engine(msn *task.Mission) error {
 	defer msn.Done()
 
@@ -207,6 +380,9 @@ func (self *Gwyneth) run_filter_engine(msn *task.Mission) error {
 				}
 
 				for _, f := range fs {
+					if artcl.Revision() > 0 && !f.OnUpdate() {
+						continue
+					}
 					mgr, err := self.action_mgr_idx.Get(f.Action().Id())
 					if err != nil {
 						slog.Warn("failed: cannot find action: %s", err)
@@ -236,12 +412,12 @@ func (self *Gwyneth) run_action_managers() error {
 	return nil
 }
 
-func (self *Gwyneth) run_rss_collector(msn *task.Mission) error {
+func (self *Gwyneth) run_collector(msn *task.Mission) error {
 	defer msn.Done()
 
 	slog.Debug("start collector")
 
-	p := task.NewPool(msn.New(), COLLECTOR_RSS_POOL_SIZE)
+	p := task.NewPool(msn.New(), self.cfg.Collector.Concurrency)
 	defer p.Close()
 
 	src_s, err := self.tv.GetSources()
@@ -251,64 +427,65 @@ func (self *Gwyneth) run_rss_collector(msn *task.Mission) error {
 
 	tgts := []*model.Source{}
 	for _, src := range src_s {
-		if src.Type().IsUserCreate() {
-			continue
-		}
-		if !(src.Type().Name() == "rss") {
+		if src.IsPause() {
 			continue
 		}
-		if src.IsPause() {
+		if _, err := collector.Lookup(src.Type()); err != nil {
 			continue
 		}
 
 		tgts = append(tgts, src)
 	}
+	self.sched.Sync(tgts, time.Now(), self.restoreNextFetch)
 	if len(tgts) < 1 {
-		slog.Info("rss collector is zero")
+		slog.Info("collector target is zero")
 		return nil
 	}
 
-	loop_sec := 60 * 5 //WIP: to config
+	for _, tgt := range tgts {
+		clctr, _ := collector.Lookup(tgt.Type())
+		w, ok := clctr.(collector.Watcher)
+		if !ok {
+			continue
+		}
+
+		go func(msn *task.Mission, src *model.Source) {
+			defer msn.Done()
+
+			notice := func() {
+				self.sched.Trigger(src.Id(), time.Now())
+			}
+			if err := w.Watch(msn.New(), src, notice); err != nil {
+				slog.Warn("failed: cannot watch '%s': %s", src.Title(), err)
+			}
+		}(msn.New(), tgt)
+	}
 
-	tgts_s := split_src(loop_sec, tgts)
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
-	now := 0
 	for {
 		select {
 		case <- msn.RecvCancel():
 			return nil
-		case <- ticker.C:
-			func () {
-				defer func(){
-					now++
-					if now >= loop_sec {
-						now = 0
-					}
-				}()
-
-				if len(tgts_s) <= now {
-					return
-				}
-
-				tgts := tgts_s[now]
-				if tgts == nil {
-					return
-				}
-
-				for _, tgt := range tgts {
-					p.Do(rss_collector, msn.New(), self.lm.GetCollectorsLogger(), self.status_mgr, tgt, self.artcl_ch)
-				}
-			}()
+		case now := <- ticker.C:
+			for _, tgt := range self.sched.Due(now) {
+				p.Do(self.collect, msn.New(), tgt)
+			}
+		case <- self.fetch_req.Recv():
+			for _, tgt := range self.sched.Due(time.Now()) {
+				p.Do(self.collect, msn.New(), tgt)
+			}
 		}
 	}
 }
 
 func (self *Gwyneth) checkAndInitSourceTypes() error {
 	defaults := map[string]string{
-		"rss": "rss",
 		"noop": "noop",
 	}
+	for _, name := range collector.Names() {
+		defaults[name] = name
+	}
 	self.default_source_type = make(map[string]struct{})
 
 	sts, err := self.tv.GetSourceTypes()
@@ -352,8 +529,57 @@ func (self *Gwyneth) DeleteSourceType(id *model.Id) error {
 	return self.tv.DeleteSourceType(id)
 }
 
-func (self *Gwyneth) AddSource(title string, src_type_id *model.Id, source string) (*model.Source, error) {
-	s, err := self.tv.AddSource(title, src_type_id, source)
+func (self *Gwyneth) AddSource(title string, src_type_id *model.Id, source string, interval int, group string, auto_pick bool) (*model.Source, error) {
+	if err := checkInterval(interval); err != nil {
+		return nil, err
+	}
+	is_rss, err := self.isRssSource(src_type_id)
+	if err != nil {
+		return nil, err
+	}
+	if is_rss {
+		feed, err := self.discoverFeed(source, auto_pick, nil)
+		if err != nil {
+			return nil, err
+		}
+		source = feed.Url
+		if title == "" {
+			title = feed.Title
+		}
+	}
+
+	s, err := self.tv.AddSource(title, src_type_id, source, interval, group)
+	if err != nil {
+		return nil, err
+	}
+
+	self.new_src.Notice()
+	return s, nil
+}
+
+func (self *Gwyneth) UpdateSource(id *model.Id, title string, source string, interval int, group string) (*model.Source, error) {
+	if err := checkInterval(interval); err != nil {
+		return nil, err
+	}
+	cur, err := self.tv.GetSource(id)
+	if err != nil {
+		return nil, err
+	}
+	is_rss, err := self.isRssSource(cur.Type().Id())
+	if err != nil {
+		return nil, err
+	}
+	if is_rss && cur.Value() != source {
+		opt, err := self.tv.GetSourceOption(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := self.validateFeed(source, opt.Http); err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := self.tv.UpdateSource(id, title, source, interval, group)
 	if err != nil {
 		return nil, err
 	}
@@ -370,8 +596,23 @@ func (self *Gwyneth) GetSources() ([]*model.Source, error) {
 	return self.tv.GetSources()
 }
 
-func (self *Gwyneth) GetSourceStatus(id *model.Id) []*model.Status {
-	return self.status_mgr.Get(id)
+func (self *Gwyneth) GetSourceOption(id *model.Id) (*model.SourceOption, error) {
+	return self.tv.GetSourceOption(id)
+}
+
+func (self *Gwyneth) UpdateSourceOption(id *model.Id, opt *model.SourceOption) error {
+	if err := self.CheckSourceOption(opt); err != nil {
+		return err
+	}
+	return self.tv.UpdateSourceOption(id, opt)
+}
+
+// CheckSourceOption validates the option, and fills the token of the hook if it is empty.
+func (self *Gwyneth) CheckSourceOption(opt *model.SourceOption) error {
+	if _, err := collector.NewHttpClient(opt.Http, ""); err != nil {
+		return err
+	}
+	return checkHookOption(opt.Hook)
 }
 
 func (self *Gwyneth) FindSource(kw string) ([]*model.Source, error) {
@@ -382,6 +623,7 @@ func (self *Gwyneth) RemoveSource(id *model.Id) error {
 	if err := self.tv.RemoveSource(id); err != nil {
 		return err
 	}
+	go self.unsubscribe(self.msn.New(), id)
 
 	self.new_src.Notice()
 	return nil
@@ -391,6 +633,7 @@ func (self *Gwyneth) PauseSource(id *model.Id) error {
 	if err := self.tv.PauseSource(id); err != nil {
 		return err
 	}
+	go self.unsubscribe(self.msn.New(), id)
 
 	self.new_src.Notice()
 	return nil
@@ -401,14 +644,50 @@ func (self *Gwyneth) ResumeSource(id *model.Id) error {
 		return err
 	}
 
+	// a quarantined source is retried from the start.
+	st, err := self.tv.GetSourceState(id)
+	if err != nil {
+		return err
+	}
+	if st.Failures > 0 || st.Quarantine != "" {
+		st.Failures = 0
+		st.Permanent = false
+		st.Quarantine = ""
+		st.Quarantined = 0
+		if err := self.tv.UpdateSourceState(id, st); err != nil {
+			return err
+		}
+	}
+
 	self.new_src.Notice()
 	return nil
 }
 
-func (self *Gwyneth) AddArticle(title string, body string, link string, utime int64, raw string, src_id *model.Id) (*model.Article, error){
-	a, err := self.addArticle(title, body, link, utime, raw, src_id)
+// PushArticle records the article like a collected one, and returns an error if it cannot be recorded.
+func (self *Gwyneth) PushArticle(artcl *model.Article) error {
+	started := time.Now()
+	job := &collector.Job{
+		Logger: self.lm.GetCollectorsLogger(),
+		Src: artcl.Src(),
+	}
+	new_artcls, err := self.runJob(self.msn.New(), job, false, func() {
+		msn := self.msn.New()
+		defer msn.Done()
+
+		job.Send(msn, artcl)
+	})
+	if err != nil {
+		self.addFetchLog(artcl.Src().Id(), started, collector.MakeFailedStatus("%s", err), job, new_artcls)
+		return err
+	}
+	self.addFetchLog(artcl.Src().Id(), started, collector.MakeSucceededStatus("Succeeded: pushed"), job, new_artcls)
+	return nil
+}
+
+func (self *Gwyneth) AddArticle(title string, body string, link string, utime int64, raw string, meta *model.ArticleMeta, src_id *model.Id) (*model.Article, error){
+	a, err := self.addArticle(title, body, link, utime, raw, meta, src_id)
 	if err != nil {
-		if err != errors.ERR_ALREADY_EXIST_ARTICLE {
+		if err != errors.ERR_ALREADY_EXIST_ARTICLE && err != errors.ERR_UPDATED_ARTICLE {
 			return nil, err
 		}
 	}
@@ -419,8 +698,12 @@ func (self *Gwyneth) AddArticle(title string, body string, link string, utime in
 	return a, nil
 }
 
-func (self *Gwyneth) addArticle(title string, body string, link string, utime int64, raw string, src_id *model.Id) (*model.Article, error){
-	return self.tv.AddArticle(title, body, link, utime, raw, src_id)
+func (self *Gwyneth) addArticle(title string, body string, link string, utime int64, raw string, meta *model.ArticleMeta, src_id *model.Id) (*model.Article, error){
+	return self.tv.AddArticle(title, body, link, utime, raw, meta, src_id)
+}
+
+func (self *Gwyneth) GetArticleRevisions(id *model.Id) ([]*model.ArticleRevision, error) {
+	return self.tv.GetArticleRevisions(id)
 }
 
 func (self *Gwyneth) RemoveArticle(id *model.Id) error {
@@ -431,12 +714,12 @@ func (self *Gwyneth) removeArticle(id *model.Id) error {
 	return self.tv.RemoveArticle(id)
 }
 
-func (self *Gwyneth) LookupArticles(t_kw string, b_kw string, src_ids []*model.Id, start int64, end int64, limit int64) ([]*model.Article, error) {
-	return self.lookupArticles(t_kw, b_kw, src_ids, start, end, limit)
+func (self *Gwyneth) LookupArticles(t_kw string, b_kw string, a_kw string, c_kw string, src_ids []*model.Id, start int64, end int64, limit int64) ([]*model.Article, error) {
+	return self.lookupArticles(t_kw, b_kw, a_kw, c_kw, src_ids, start, end, limit)
 }
 
-func (self *Gwyneth) lookupArticles(t_kw string, b_kw string, src_ids []*model.Id, start int64, end int64, limit int64) ([]*model.Article, error) {
-	return self.tv.LookupArticles(t_kw, b_kw, src_ids, start, end, limit)
+func (self *Gwyneth) lookupArticles(t_kw string, b_kw string, a_kw string, c_kw string, src_ids []*model.Id, start int64, end int64, limit int64) ([]*model.Article, error) {
+	return self.tv.LookupArticles(t_kw, b_kw, a_kw, c_kw, src_ids, start, end, limit)
 }
 
 func (self *Gwyneth) GetFeed(src_id *model.Id, limit int64) ([]*model.Article, error) {
@@ -606,11 +889,11 @@ func (self *Gwyneth) redriveActionDlqItem(action_id *model.Id, q_item_id *model.
 	return mgr.Redrive(q_item_id)
 }
 
-func (self *Gwyneth) AddFilter(title string, regex_title bool, body string, regex_body bool, action_id *model.Id) (*filter.Filter, error) {
-	return self.addFilter(title, regex_title, body, regex_body, action_id)
+func (self *Gwyneth) AddFilter(title string, regex_title bool, body string, regex_body bool, on_update bool, action_id *model.Id) (*filter.Filter, error) {
+	return self.addFilter(title, regex_title, body, regex_body, on_update, action_id)
 }
 
-func (self *Gwyneth) addFilter(title string, regex_title bool, body string, regex_body bool, action_id *model.Id) (*filter.Filter, error) {
+func (self *Gwyneth) addFilter(title string, regex_title bool, body string, regex_body bool, on_update bool, action_id *model.Id) (*filter.Filter, error) {
 	if regex_title {
 		_, err := regexp.Compile(title)
 		if err != nil {
@@ -624,7 +907,7 @@ func (self *Gwyneth) addFilter(title string, regex_title bool, body string, rege
 		}
 	}
 
-	f, err := self.tv.AddFilter(title, regex_title, body, regex_body, action_id)
+	f, err := self.tv.AddFilter(title, regex_title, body, regex_body, on_update, action_id)
 	if err != nil {
 		return nil, err
 	}
@@ -647,6 +930,20 @@ func (self *Gwyneth) updateFilterAction(id *model.Id, action_id *model.Id) (*fil
 	return f, nil
 }
 
+func (self *Gwyneth) UpdateFilterOnUpdate(id *model.Id, on_update bool) (*filter.Filter, error) {
+	return self.updateFilterOnUpdate(id, on_update)
+}
+
+func (self *Gwyneth) updateFilterOnUpdate(id *model.Id, on_update bool) (*filter.Filter, error) {
+	f, err := self.tv.UpdateFilterOnUpdate(id, on_update)
+	if err != nil {
+		return nil, err
+	}
+
+	self.filter_cond.Notice()
+	return f, nil
+}
+
 func (self *Gwyneth) GetFilters() ([]*filter.Filter, error) {
 	return self.getFilters()
 }
@@ -741,66 +1038,175 @@ func (self *Gwyneth) ReFilter(src_id *model.Id, limit int64) error {
 	return nil
 }
 
-func makeFailedStatus(s string, msg ...any) *model.Status {
-	return &model.Status{
-		Unixtime: int(time.Now().Unix()),
-		IsSuccess: false,
-		Log: fmt.Sprintf(s, msg...),
+func (self *Gwyneth) collect(msn *task.Mission, args ...any) {
+	defer msn.Done()
+
+	logger := self.lm.GetCollectorsLogger()
+	src := args[0].(*model.Source)
+
+	started := time.Now()
+	self.fetch_mgr.Start(src.Id(), started)
+
+	if task.IsCanceled(msn) {
+		self.sched.Release(src.Id())
+
+		msg := fmt.Sprintf("the collector of '%s' is canceld", src.Title())
+		self.updateStatus(src.Id(), started, collector.MakeFailedStatus(msg), nil, 0)
+		logger.Info(msg)
+		return
 	}
-}
 
-func makeSucceededStatus(s string, msg ...any) *model.Status {
-	return &model.Status{
-		Unixtime: int(time.Now().Unix()),
-		IsSuccess: true,
-		Log: fmt.Sprintf(s, msg...),
+	clctr, err := collector.Lookup(src.Type())
+	if err != nil {
+		self.sched.Done(src.Id(), time.Now(), nil)
+
+		logger.Warn("cannot collect '%s/%s': %s", src.Title(), src.Value(), err)
+		self.updateStatus(src.Id(), started, collector.MakeFailedStatus("%s", err), nil, 0)
+		return
 	}
-}
 
-func rss_collector(msn *task.Mission, args ...any) {
-	defer msn.Done()
+	state, err := self.tv.GetSourceState(src.Id())
+	if err != nil {
+		logger.Warn("cannot load the state of '%s': %s", src.Title(), err)
+		state = &model.SourceState{}
+	}
 
-	logger := args[0].(*slog.Logger)
-	status_mgr := args[1].(*statusManager)
-	src := args[2].(*model.Source)
-	artcl_ch := args[3].(chan *model.Article)
+	job := &collector.Job{
+		Logger: logger,
+		Src: src,
+		State: state.Copy(),
+	}
+	if opt, err := self.tv.GetSourceOption(src.Id()); err != nil {
+		logger.Warn("cannot load the option of '%s': %s", src.Title(), err)
+	} else if job.Http, err = self.newHttpClient(opt.Http); err != nil {
+		self.sched.Done(src.Id(), time.Now(), nil)
+
+		logger.Warn("cannot collect '%s/%s': %s", src.Title(), src.Value(), err)
+		self.updateStatus(src.Id(), started, collector.MakeErrorStatus(collector.Permanent(err)), nil, 0)
+		return
+	}
+
+	logger.Debug("the collector of '%s' is running... :'%s'", src.Title(), src.Value())
+	var st *model.Status
+	new_artcls, err := self.runJob(msn.New(), job, false, func() {
+		st = clctr.Collect(msn.New(), job)
+	})
+	if err != nil && st.IsSuccess {
+		// the collection fails so that the validators and the cursor are not saved, and the articles are read again.
+		st = collector.MakeFailedStatus("%s", err)
+	}
+	if !st.IsSuccess && task.IsCanceled(msn) {
+		// the collectors are restarted by gwyneth, e.g. on a change of the sources. it is not a failure of the source,
+		// so the source is collected again without touching the failures.
+		self.sched.Release(src.Id())
 
-	if task.IsCanceled(msn) {
 		msg := fmt.Sprintf("the collector of '%s' is canceld", src.Title())
-		status_mgr.Update(src.Id(), makeFailedStatus(msg))
+		self.updateStatus(src.Id(), started, collector.MakeFailedStatus(msg), job, new_artcls)
 		logger.Info(msg)
 		return
 	}
 
-	logger.Debug("the collector of '%s' is running... :'%s'", src.Title(), src.Value())
-	if err := rss.GetFeed(msn.New(), logger, src, artcl_ch); err != nil {
-		logger.Warn("cannot collect '%s/%s': %s", src.Title(), src.Value(), err)
-		status_mgr.Update(src.Id(), makeFailedStatus("%s", err))
+	next_state := job.State
+	if st.IsSuccess {
+		if latest := job.Latest(); latest > next_state.LastUpdate {
+			next_state.LastUpdate = latest
+			next_state.Idle = 0
+		} else {
+			next_state.Idle++
+		}
+		next_state.Failures = 0
+		next_state.Permanent = false
+	} else {
+		// the articles of a failed collection might not be recorded, so only Retry-After is kept.
+		next_state = state.Copy()
+		next_state.RetryAfter = job.State.RetryAfter
+		next_state.Failures++
+		next_state.Permanent = st.IsPermanent
+	}
+
+	quarantine := !st.IsSuccess && self.cfg.Collector.IsQuarantine(next_state.Failures, next_state.Permanent)
+	if quarantine {
+		next_state.Quarantine = fmt.Sprintf("paused after %d consecutive failures: %s", next_state.Failures, st.Log)
+		next_state.Quarantined = time.Now().Unix()
+	}
+	next := self.sched.Done(src.Id(), time.Now(), next_state)
+	if !next.IsZero() {
+		next_state.NextFetch = next.Unix()
+	}
+
+	if !state.Equal(next_state) {
+		if err := self.tv.UpdateSourceState(src.Id(), next_state); err != nil {
+			logger.Warn("cannot save the state of '%s': %s", src.Title(), err)
+		}
+	}
+	if st.IsSuccess {
+		if err := self.subscribe(msn.New(), src, next_state); err != nil {
+			logger.Warn("cannot subscribe '%s' via websub: %s", src.Title(), err)
+		}
+	}
+
+	if quarantine {
+		logger.Warn("'%s' is paused: %s", src.Title(), next_state.Quarantine)
+		if err := self.PauseSource(src.Id()); err != nil {
+			logger.Warn("cannot pause '%s': %s", src.Title(), err)
+		}
+	}
+
+	if !st.IsSuccess {
+		logger.Warn("cannot collect '%s/%s': %s", src.Title(), src.Value(), st.Log)
+		self.updateStatus(src.Id(), started, st, job, new_artcls)
 		return
 	}
-	logger.Debug("the collector of '%s' done!!!", src.Title())
-	status_mgr.Update(src.Id(), makeSucceededStatus("Succeeded"))
+	logger.Debug("the collector of '%s' done!!! next: %s", src.Title(), next)
+	self.updateStatus(src.Id(), started, st, job, new_artcls)
 }
 
-func split_src(size int, src_s []*model.Source) [][]*model.Source {
-	if len(src_s) < 1 {
-		return make([][]*model.Source, 0, 0)
+// newHttpClient makes the client of a source with the credential from the secrets store.
+func (self *Gwyneth) newHttpClient(opt *model.HttpOption) (*collector.HttpClient, error) {
+	if opt == nil {
+		return nil, nil
 	}
 
-	bkt_size := len(src_s) / size
-	if bkt_size < 1 {
-		bkt_size = 1
+	credential := ""
+	if opt.Auth != model.HTTP_AUTH_NONE && opt.Credential != "" {
+		val, err := self.secrets.Get(opt.Credential)
+		if err != nil {
+			return nil, err
+		}
+		credential = val
 	}
+	return collector.NewHttpClient(opt, credential)
+}
 
-	ret := make([][]*model.Source, 0, size)
-	for i := 0; i < len(src_s); i += bkt_size {
-		end := i + bkt_size
-		if end > len(src_s) {
-			end = len(src_s)
-		}
-		ret = append(ret, src_s[i:end])
+func (self *Gwyneth) updateStatus(id *model.Id, started time.Time, st *model.Status, job *collector.Job, new_artcls int) {
+	self.fetch_mgr.Finish(id, started, st)
+	self.addFetchLog(id, started, st, job, new_artcls)
+}
+
+func (self *Gwyneth) restoreNextFetch(src *model.Source) time.Time {
+	st, err := self.tv.GetSourceState(src.Id())
+	if err != nil || st.NextFetch < 1 {
+		return time.Time{}
 	}
-	return ret
+	return time.Unix(st.NextFetch, 0)
+}
+
+func (self *Gwyneth) GetSourceState(id *model.Id) (*model.SourceState, error) {
+	return self.tv.GetSourceState(id)
+}
+
+func (self *Gwyneth) GetSourceNextFetch(id *model.Id) (time.Time, bool) {
+	return self.sched.Next(id)
+}
+
+func checkInterval(interval int) error {
+	if interval < 0 {
+		return fmt.Errorf("interval is negative: %d", interval)
+	}
+	if interval > 0 && interval < config.MIN_COLLECTOR_INTERVAL {
+		return fmt.Errorf("interval is too short: %d < %d", interval, config.MIN_COLLECTOR_INTERVAL)
+	}
+	return nil
 }
 
 type noticer struct {
@@ -828,49 +1234,18 @@ func (self *noticer) Notice() {
 	}()
 }
 
-type statusManager struct {
-	sts_idx map[string][]*model.Status
-
-	mtx *sync.RWMutex
+// record is an article to be recorded. result receives the error of the recording, if it is set.
+type record struct {
+	artcl  *model.Article
+	result chan <- error
+	quiet  bool // the article is not passed to the filters.
 }
 
-func newStatusManager() *statusManager {
-	return &statusManager{
-		sts_idx: make(map[string][]*model.Status),
-		mtx: new(sync.RWMutex),
-	}
-}
-
-func (sm *statusManager) Update(id *model.Id, status *model.Status) {
-	sm.mtx.Lock()
-	defer sm.mtx.Unlock()
-
-	sts, ok := sm.sts_idx[id.String()]
-	if !ok {
-		sts = make([]*model.Status, 0, 5)
-	}
-	if len(sts) >= 5 {
-		new_sts := make([]*model.Status, 0, 5)
-		new_sts = append(new_sts, sts[1:]...)
-		sts = new_sts
-	}
-	sts = append(sts, status)
-
-	sm.sts_idx[id.String()] = sts
-}
-
-func (sm *statusManager) Get(id *model.Id) []*model.Status {
-	sm.mtx.RLock()
-	defer sm.mtx.RUnlock()
-
-	sts, ok := sm.sts_idx[id.String()]
-	if !ok {
-		return make([]*model.Status, 0, 0)
+func (self *record) done(err error) {
+	if self.result == nil {
+		return
 	}
-
-	ret_sts := make([]*model.Status, len(sts))
-	copy(ret_sts, sts)
-	return ret_sts
+	self.result <- err
 }
 
 type actionManagerIndex struct {
@@ -0,0 +1,118 @@
+package gwyneth
+
+import (
+	"time"
+)
+
+import (
+	"github.com/l4go/task"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/slog"
+	"github.com/hinoshiba/gwyneth/model"
+
+	"github.com/hinoshiba/gwyneth/collector"
+)
+
+const (
+	SOURCE_STATUS_SIZE = 5
+	HISTORY_CLEAN_INTERVAL = 1 * time.Hour
+)
+
+// GetSourceStatus returns the statuses of the latest collections in chronological order.
+func (self *Gwyneth) GetSourceStatus(id *model.Id) []*model.Status {
+	logs, err := self.tv.GetFetchLogs(id, 0, SOURCE_STATUS_SIZE)
+	if err != nil {
+		slog.Warn("failed: cannot get the fetch logs of '%s': %s", id.String(), err)
+		return []*model.Status{}
+	}
+
+	sts := make([]*model.Status, 0, len(logs))
+	for _, l := range logs {
+		sts = append(sts, l.Status())
+	}
+	return sts
+}
+
+// GetSourceStatuses returns the statuses of the latest collections of all the sources by a query.
+// The key of the map is the id of the source.
+func (self *Gwyneth) GetSourceStatuses() map[string][]*model.Status {
+	logs, err := self.tv.GetLatestFetchLogs(SOURCE_STATUS_SIZE)
+	if err != nil {
+		slog.Warn("failed: cannot get the latest fetch logs: %s", err)
+		return map[string][]*model.Status{}
+	}
+
+	ret := make(map[string][]*model.Status, len(logs))
+	for key, ls := range logs {
+		sts := make([]*model.Status, 0, len(ls))
+		for _, l := range ls {
+			sts = append(sts, l.Status())
+		}
+		ret[key] = sts
+	}
+	return ret
+}
+
+// GetFetchLogs returns the latest collections since the unixtime in chronological order.
+func (self *Gwyneth) GetFetchLogs(id *model.Id, since int64, limit int64) ([]*model.FetchLog, error) {
+	if _, err := self.tv.GetSource(id); err != nil {
+		return nil, err
+	}
+	return self.tv.GetFetchLogs(id, since, limit)
+}
+
+func (self *Gwyneth) addFetchLog(id *model.Id, started time.Time, st *model.Status, job *collector.Job, new_artcls int) {
+	l := &model.FetchLog{
+		Unixtime: started.Unix(),
+		Duration: time.Since(started),
+		NewArticles: new_artcls,
+		IsSuccess: st.IsSuccess,
+		IsPermanent: st.IsPermanent,
+		Log: st.Log,
+	}
+	if job != nil {
+		l.HttpStatus = job.HttpStatus()
+		l.Bytes = job.Bytes()
+		l.Items = job.Items()
+	}
+
+	if err := self.tv.AddFetchLog(id, l); err != nil {
+		slog.Warn("failed: cannot save the fetch log of '%s': %s", id.String(), err)
+	}
+}
+
+// run_history_cleaner removes the fetch logs which are older than the retention.
+func (self *Gwyneth) run_history_cleaner(msn *task.Mission) {
+	defer msn.Done()
+
+	retention := self.cfg.Collector.HistoryRetention
+	if retention < 0 {
+		return
+	}
+
+	clean := func() {
+		before := time.Now().AddDate(0, 0, -retention)
+		n, err := self.tv.DeleteFetchLogs(before.Unix())
+		if err != nil {
+			slog.Warn("failed: cannot remove the old fetch logs: %s", err)
+			return
+		}
+		if n > 0 {
+			slog.Debug("removed %d fetch logs before %s", n, before)
+		}
+	}
+	clean()
+
+	ticker := time.NewTicker(HISTORY_CLEAN_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <- msn.RecvCancel():
+			return
+		case <- ticker.C:
+			clean()
+		}
+	}
+}
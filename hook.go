@@ -0,0 +1,204 @@
+package gwyneth
+
+import (
+	"fmt"
+	"time"
+	"strings"
+	"crypto/subtle"
+	"encoding/json"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/model/external"
+
+	"github.com/hinoshiba/gwyneth/collector"
+	"github.com/hinoshiba/gwyneth/collector/jsonapi"
+	"github.com/hinoshiba/gwyneth/collector/websub"
+)
+
+var (
+	ErrHookNotFound     error = fmt.Errorf("the source does not accept a hook.")
+	ErrHookUnauthorized error = fmt.Errorf("the token or the signature is invalid.")
+	ErrHookNotRecorded  error = fmt.Errorf("cannot record the articles.")
+)
+
+// HookRequest is the credentials of a request to the webhook.
+type HookRequest struct {
+	Token     string   // the bearer token or the value of X-Gwyneth-Token.
+	Signature []string // the values of X-Hub-Signature-256 and X-Hub-Signature.
+}
+
+// ReceiveHook records the articles of the body which is pushed to the source, and returns the count of them.
+func (self *Gwyneth) ReceiveHook(src_id *model.Id, req *HookRequest, body []byte) (int, error) {
+	src, err := self.tv.GetSource(src_id)
+	if err != nil {
+		return 0, ErrHookNotFound
+	}
+	opt, err := self.tv.GetSourceOption(src_id)
+	if err != nil {
+		return 0, err
+	}
+	if opt.Hook == nil || opt.Hook.Token == "" {
+		return 0, ErrHookNotFound
+	}
+	if !verifyHook(opt.Hook, req, body) {
+		return 0, ErrHookUnauthorized
+	}
+	if src.IsPause() {
+		return 0, fmt.Errorf("the source is paused.")
+	}
+
+	artcls, err := makeHookArticles(src, opt.Hook.Template, body, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	started := time.Now()
+	job := &collector.Job{
+		Logger: self.lm.GetCollectorsLogger(),
+		Src: src,
+	}
+	new_artcls, err := self.runJob(self.msn.New(), job, false, func() {
+		msn := self.msn.New()
+		defer msn.Done()
+
+		for _, artcl := range artcls {
+			job.Send(msn, artcl)
+		}
+	})
+	if err != nil {
+		self.addFetchLog(src.Id(), started, collector.MakeFailedStatus("%s", err), job, new_artcls)
+		return 0, ErrHookNotRecorded
+	}
+	self.addFetchLog(src.Id(), started, collector.MakeSucceededStatus("Succeeded: hooked"), job, new_artcls)
+	return len(artcls), nil
+}
+
+func verifyHook(opt *model.HookOption, req *HookRequest, body []byte) bool {
+	if opt.Hmac {
+		for _, sig := range req.Signature {
+			if sig != "" && websub.VerifySignature(opt.Token, sig, body) {
+				return true
+			}
+		}
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(req.Token), []byte(opt.Token)) == 1
+}
+
+// makeHookArticles reads the body as an article or a list of the articles, or maps it by the template.
+func makeHookArticles(src *model.Source, tmpl *model.HookTemplate, body []byte, now time.Time) ([]*model.Article, error) {
+	if tmpl != nil {
+		return makeTemplateArticles(src, tmpl, body, now)
+	}
+
+	ex_artcls := []*external.Article{}
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		if err := json.Unmarshal(body, &ex_artcls); err != nil {
+			return nil, fmt.Errorf("the body is not a json of the articles: %s", err)
+		}
+	} else {
+		var ex_artcl external.Article
+		if err := json.Unmarshal(body, &ex_artcl); err != nil {
+			return nil, fmt.Errorf("the body is not a json of an article: %s", err)
+		}
+		ex_artcls = append(ex_artcls, &ex_artcl)
+	}
+
+	artcls := []*model.Article{}
+	for _, ex_artcl := range ex_artcls {
+		if ex_artcl.Title == "" && ex_artcl.Body == "" {
+			return nil, fmt.Errorf("title and body are empty")
+		}
+		utime := int64(ex_artcl.Timestamp)
+		if utime < 1 {
+			utime = now.Unix()
+		}
+		raw := ex_artcl.Raw
+		if raw == "" {
+			b, err := json.Marshal(ex_artcl)
+			if err != nil {
+				return nil, err
+			}
+			raw = string(b)
+		}
+
+		artcl := model.NewArticle(nil, src, ex_artcl.Title, ex_artcl.Body, ex_artcl.Link, utime, raw)
+		artcls = append(artcls, artcl.WithMeta(model.ImportExternalArticleMeta(ex_artcl)))
+	}
+	return artcls, nil
+}
+
+func makeTemplateArticles(src *model.Source, tmpl *model.HookTemplate, body []byte, now time.Time) ([]*model.Article, error) {
+	cfg := newHookConfig(src, tmpl)
+
+	var doc any
+	dec := json.NewDecoder(strings.NewReader(string(body)))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("the body is not a json: %s", err)
+	}
+	elems, err := jsonapi.Lookup(doc, cfg.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	artcls := []*model.Article{}
+	for _, elem := range elems {
+		artcl, err := cfg.MakeArticle(src, elem, now)
+		if err != nil {
+			return nil, err
+		}
+		artcls = append(artcls, artcl)
+	}
+	return artcls, nil
+}
+
+// newHookConfig makes the config of jsonapi from the template. The id of an element is the guid of the article.
+func newHookConfig(src *model.Source, tmpl *model.HookTemplate) *jsonapi.Config {
+	cfg := &jsonapi.Config{
+		Url: fmt.Sprintf("hook:%s", src.Id().String()),
+		Items: tmpl.Items,
+		Id: tmpl.Id,
+		Title: tmpl.Title,
+		Body: tmpl.Body,
+		Link: tmpl.Link,
+		Timestamp: tmpl.Timestamp,
+		TimestampFormat: tmpl.TimestampFormat,
+	}
+	if cfg.Items == "" {
+		cfg.Items = "$"
+	}
+	return cfg
+}
+
+// checkHookOption fills the token of a new hook, and checks the template.
+func checkHookOption(opt *model.HookOption) error {
+	if opt == nil {
+		return nil
+	}
+	if opt.Token == "" {
+		token, err := websub.NewSecret()
+		if err != nil {
+			return err
+		}
+		opt.Token = token
+	}
+	if opt.Template == nil {
+		return nil
+	}
+	if opt.Template.Title == "" {
+		return fmt.Errorf("title path of the template is empty")
+	}
+	for _, path := range []string{opt.Template.Items, opt.Template.Id, opt.Template.Title,
+			opt.Template.Body, opt.Template.Link, opt.Template.Timestamp} {
+		if path == "" {
+			continue
+		}
+		if _, err := jsonapi.Lookup(nil, path); err != nil {
+			return fmt.Errorf("cannot parse the path of the template '%s': %s", path, err)
+		}
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package http
 
 import (
+	"mime"
+	"path"
 	"time"
+	"strconv"
+	"net/url"
 )
 
 import (
@@ -22,6 +26,15 @@ func makeFeed(cfg *config.Feed, as []*model.Article) (*feeds.Feed, error) {
 		t := time.Unix(article.Unixtime(), 0)
 		t_jst := t.In(consts.TZ_JST)
 
+		meta := article.Meta()
+		content := article.Raw()
+		if meta.Content != "" {
+			content = meta.Content
+		}
+		if article.FullText() != "" {
+			content = article.FullText()
+		}
+
 		items[i] = &feeds.Item{
 			Title: article.Title(),
 			Description: article.Body(),
@@ -29,7 +42,11 @@ func makeFeed(cfg *config.Feed, as []*model.Article) (*feeds.Feed, error) {
 			Source: &feeds.Link{Href: article.Src().Value()},
 			Id: article.Id().String(),
 			Created: t_jst,
-			Content: article.Raw(),
+			Content: content,
+			Enclosure: getEnclosure(meta),
+		}
+		if meta.Author != "" {
+			items[i].Author = &feeds.Author{Name: meta.Author}
 		}
 
 		if !(lt < article.Unixtime()) {
@@ -55,3 +72,28 @@ func makeFeed(cfg *config.Feed, as []*model.Article) (*feeds.Feed, error) {
 		Items:       items,
 	}, nil
 }
+
+// getEnclosure returns the first enclosure, because a item of the feeds has only one.
+// The lead image is the enclosure if the article has no enclosure.
+func getEnclosure(meta *model.ArticleMeta) *feeds.Enclosure {
+	for _, enc := range meta.Enclosures {
+		return &feeds.Enclosure{
+			Url: enc.Url,
+			Type: enc.Type,
+			Length: strconv.FormatInt(enc.Length, 10),
+		}
+	}
+
+	if meta.Image == "" {
+		return nil
+	}
+	u, err := url.Parse(meta.Image)
+	if err != nil {
+		return nil
+	}
+	m_type := mime.TypeByExtension(path.Ext(u.Path))
+	if m_type == "" {
+		return nil
+	}
+	return &feeds.Enclosure{Url: meta.Image, Type: m_type, Length: "0"}
+}
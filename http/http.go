@@ -1,7 +1,10 @@
 package http
 
 import (
+	"io"
 	"fmt"
+	"bytes"
+	"errors"
 	"net"
 	"net/http"
 	"net/url"
@@ -24,6 +27,7 @@ import (
 	"github.com/hinoshiba/gwyneth/config"
 	"github.com/hinoshiba/gwyneth/model"
 	"github.com/hinoshiba/gwyneth/model/external"
+	"github.com/hinoshiba/gwyneth/filter"
 )
 
 func init() {
@@ -179,6 +183,10 @@ func (self *Router) mapRoute(g *gwyneth.Gwyneth) error {
 		))
 	})
 
+	self.engine.GET("/websub/:id", getHandlerVerifyWebSub(g))
+	self.engine.POST("/websub/:id", getHandlerReceiveWebSub(self.cfg.Collector.WebSub, g))
+	self.engine.POST("/hook/:source_id", getHandlerReceiveHook(self.cfg.Http, g))
+
 	api := self.engine.Group("/api")
 	api.GET("/ping", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -187,12 +195,16 @@ func (self *Router) mapRoute(g *gwyneth.Gwyneth) error {
 	})
 
 	api.GET("/source_type", getHandlerGetSourceTypes(g))
-	api.POST("/source_type", getHandlerAddSourceType(g))
-	api.DELETE("source_type", getHandlerDeleteSourceType(g))
+	api.POST("/source_type", getHandlerAddSourceType(self.cfg.Http, g))
+	api.DELETE("source_type", getHandlerDeleteSourceType(self.cfg.Http, g))
 
 	api.GET("/source", getHandlerGetSources(g))
 	api.POST("/source", getHandlerAddSource(g))
+	api.PATCH("/source", getHandlerUpdateSource(g))
 	api.DELETE("/source", getHandlerRemoveSource(g))
+	api.POST("/source/import", getHandlerImportSources(g))
+	api.GET("/source/export", getHandlerExportSources(g))
+	api.POST("/source/preview", getHandlerPreviewSource(g))
 
 	api.GET("/source/:id", getHandlerGetSource(g))
 	api.POST("/source/:id/filter", getHandlerBindFilter(g))
@@ -200,10 +212,16 @@ func (self *Router) mapRoute(g *gwyneth.Gwyneth) error {
 	api.DELETE("/source/:id/filter", getHandlerUnBindFilter(g))
 	api.POST("/source/:id/pause", getHandlerPauseSource(g))
 	api.POST("/source/:id/resume", getHandlerResumeSource(g))
+	api.POST("/source/:id/fetch", getHandlerFetchSource(g))
+	api.PUT("/source/:id/option", getHandlerUpdateSourceOption(g))
+	api.GET("/source/:id/fetch/:job_id", getHandlerGetFetchJob(g))
+	api.POST("/source/:id/backfill", getHandlerBackfillSource(g))
+	api.GET("/source/:id/history", getHandlerGetSourceHistory(g))
 
 	api.GET("/article", getHandlerLookupArticles(self.cfg.Feed, g))
 	api.POST("/article", getHandlerAddArticle(g))
 	api.DELETE("/article", getHandlerRemoveArticle(g))
+	api.GET("/article/:id/revisions", getHandlerGetArticleRevisions(g))
 
 	api.GET("/feed/:id", getHandlerGetFeed(self.cfg.Feed, g))
 	api.POST("/feed/:id", getHandlerPostFeed(self.cfg.Feed, g))
@@ -247,10 +265,12 @@ func (self *Router) run() error {
 	return nil
 }
 
-func getHandlerAddSourceType(g *gwyneth.Gwyneth) func(*gin.Context) {
+func getHandlerAddSourceType(cfg *config.Http, g *gwyneth.Gwyneth) func(*gin.Context) {
 	return func(c *gin.Context) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "this api is not published yet."})
-		return
+		if !cfg.SourceTypeApi {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "this api is not published. it is enabled by http.source_type_api."})
+			return
+		}
 
 		var st external.SourceType
 		if err := c.ShouldBindJSON(&st); err != nil {
@@ -303,10 +323,12 @@ func getHandlerGetSourceTypes(g *gwyneth.Gwyneth) func(*gin.Context) {
 	}
 }
 
-func getHandlerDeleteSourceType(g *gwyneth.Gwyneth) func(*gin.Context) {
+func getHandlerDeleteSourceType(cfg *config.Http, g *gwyneth.Gwyneth) func(*gin.Context) {
 	return func(c *gin.Context) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "this api is not published yet."})
-		return
+		if !cfg.SourceTypeApi {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "this api is not published. it is enabled by http.source_type_api."})
+			return
+		}
 
 		var st external.SourceType
 		if err := c.ShouldBindJSON(&st); err != nil {
@@ -333,7 +355,10 @@ func getHandlerDeleteSourceType(g *gwyneth.Gwyneth) func(*gin.Context) {
 
 func getHandlerAddSource(g *gwyneth.Gwyneth) func(*gin.Context) {
 	return func(c *gin.Context) {
-		var src external.Source
+		var src struct {
+			external.Source
+			AutoPick bool `json:"auto_pick"`
+		}
 		if err := c.ShouldBindJSON(&src); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -346,13 +371,204 @@ func getHandlerAddSource(g *gwyneth.Gwyneth) func(*gin.Context) {
 			return
 		}
 
-		added_src, err := g.AddSource(src.Title, src_type_id, src.Value)
+		// the option is checked before the source is created, so an invalid option does not leave the source.
+		var opt *model.SourceOption
+		if src.Option != nil {
+			opt = model.ImportExternalSourceOption(src.Option)
+			if err := g.CheckSourceOption(opt); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		added_src, err := g.AddSource(src.Title, src_type_id, src.Value, src.Interval, src.Group, src.AutoPick)
+		if err != nil {
+			var nf_err *gwyneth.NotFeedError
+			if errors.As(err, &nf_err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "candidates": nf_err.Candidates})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ext_src := added_src.ConvertExternal()
+		if opt != nil {
+			if err := g.UpdateSourceOption(added_src.Id(), opt); err != nil {
+				if rm_err := g.RemoveSource(added_src.Id()); rm_err != nil {
+					slog.Warn("failed: cannot remove the source '%s' whose option is not saved: %s", added_src.Id().String(), rm_err)
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			ext_src.Option = opt.ConvertExternal()
+		}
+		c.IndentedJSON(http.StatusOK, ext_src)
+	}
+}
+
+func getHandlerUpdateSource(g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		var src struct {
+			Id       string  `json:"id"`
+			Title    *string `json:"title"`
+			Value    *string `json:"value"`
+			Interval *int    `json:"interval"`
+			Group    *string `json:"group"`
+		}
+		if err := c.ShouldBindJSON(&src); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		slog.Debug("UpdateSource: request is '%v'", src)
+
+		id, err := model.ParseStringId(src.Id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		cur_src, err := g.GetSource(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		title := cur_src.Title()
+		if src.Title != nil {
+			title = *src.Title
+		}
+		value := cur_src.Value()
+		if src.Value != nil {
+			value = *src.Value
+		}
+		interval := cur_src.Interval()
+		if src.Interval != nil {
+			interval = *src.Interval
+		}
+		group := cur_src.Group()
+		if src.Group != nil {
+			group = *src.Group
+		}
+
+		updated_src, err := g.UpdateSource(id, title, value, interval, group)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.IndentedJSON(http.StatusOK, updated_src.ConvertExternal())
+	}
+}
+
+func getHandlerImportSources(g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		r := io.Reader(c.Request.Body)
+		if fh, err := c.FormFile("file"); err == nil {
+			f, err := fh.Open()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			defer f.Close()
+			r = f
+		}
+
+		added, skipped, err := g.ImportOPML(r)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ret_src := []*external.Source{}
+		for _, src := range added {
+			ret_src = append(ret_src, src.ConvertExternal())
+		}
+		c.IndentedJSON(http.StatusOK, gin.H{
+			"added": ret_src,
+			"skipped": skipped,
+		})
+	}
+}
+
+func getHandlerExportSources(g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		export_type := c.DefaultQuery("type", "opml")
+		if export_type != "opml" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unsupported type: '%s'", export_type)})
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := g.ExportOPML(&buf); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename=\"gwyneth.opml\"")
+		c.Data(http.StatusOK, "text/x-opml; charset=utf-8", buf.Bytes())
+	}
+}
+
+func getHandlerPreviewSource(g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		var src struct {
+			external.Source
+			Filters []string `json:"filters"`
+		}
+		if err := c.ShouldBindJSON(&src); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		slog.Debug("PreviewSource: request is '%v'", src)
+
+		if src.Type == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "type is empty"})
+			return
+		}
+		src_type_id, err := model.ParseStringId(src.Type.Id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		fs := []*filter.Filter{}
+		for _, f_id_base := range src.Filters {
+			f_id, err := model.ParseStringId(f_id_base)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("cannot parse filter id('%s'): %s", f_id_base, err)})
+				return
+			}
+			f, err := g.GetFilter(f_id)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			fs = append(fs, f)
+		}
+
+		artcls, warns, err := g.Preview(src_type_id, src.Value)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.IndentedJSON(http.StatusOK, added_src.ConvertExternal())
+		ret_artcls := []*external.Article{}
+		matches := map[string][]string{}
+		for _, f := range fs {
+			matches[f.Id().String()] = []string{}
+		}
+		for _, artcl := range artcls {
+			ret_artcls = append(ret_artcls, artcl.ConvertExternal())
+			for _, f := range fs {
+				if f.IsMatch(artcl) {
+					matches[f.Id().String()] = append(matches[f.Id().String()], artcl.Id().String())
+				}
+			}
+		}
+		c.IndentedJSON(http.StatusOK, gin.H{
+			"articles": ret_artcls,
+			"warnings": warns,
+			"matches": matches,
+		})
 	}
 }
 
@@ -382,12 +598,12 @@ func getHandlerGetSources(g *gwyneth.Gwyneth) func(*gin.Context) {
 			return
 		}
 
+		statuses := g.GetSourceStatuses()
 		ret_src := []*external.Source{}
 		for _, src := range srcs {
 			ext_src := src.ConvertExternal()
 
-			sts := g.GetSourceStatus(src.Id())
-			for _, st := range sts {
+			for _, st := range statuses[src.Id().String()] {
 				ext_src.Status = append(ext_src.Status, st.ConvertExternal())
 			}
 			ret_src = append(ret_src, ext_src)
@@ -440,7 +656,7 @@ func getHandlerAddArticle(g *gwyneth.Gwyneth) func(*gin.Context) {
 			return
 		}
 
-		added_article, err := g.AddArticle(article.Title, article.Body, article.Link, int64(article.Timestamp), article.Raw, src_id)
+		added_article, err := g.AddArticle(article.Title, article.Body, article.Link, int64(article.Timestamp), article.Raw, model.ImportExternalArticleMeta(&article), src_id)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -450,6 +666,24 @@ func getHandlerAddArticle(g *gwyneth.Gwyneth) func(*gin.Context) {
 	}
 }
 
+func getHandlerGetArticleRevisions(g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		id_base := c.Param("id")
+		id, err := model.ParseStringId(id_base)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		revs, err := g.GetArticleRevisions(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusOK, model.ConvertExternalRevisions(revs))
+	}
+}
+
 func getHandlerRemoveArticle(g *gwyneth.Gwyneth) func(*gin.Context) {
 	return func(c *gin.Context) {
 		var article external.Article
@@ -478,6 +712,8 @@ func getHandlerLookupArticles(cfg *config.Feed, g *gwyneth.Gwyneth) func(*gin.Co
 	return func(c *gin.Context) {
 		title_urlencode := c.Query("title")
 		body_urlencode := c.Query("body")
+		author_urlencode := c.Query("author")
+		category_urlencode := c.Query("category")
 		src_id_base_s := c.QueryArray("src_id")
 		s_start := c.DefaultQuery("start", "-1")
 		s_end := c.DefaultQuery("end", "-1")
@@ -498,6 +734,20 @@ func getHandlerLookupArticles(cfg *config.Feed, g *gwyneth.Gwyneth) func(*gin.Co
 			return
 		}
 
+		author, err := url.QueryUnescape(author_urlencode)
+		if err != nil {
+			err_msg := fmt.Sprintf("cannot parse author ('%s'): %s", author_urlencode, err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err_msg})
+			return
+		}
+
+		category, err := url.QueryUnescape(category_urlencode)
+		if err != nil {
+			err_msg := fmt.Sprintf("cannot parse category ('%s'): %s", category_urlencode, err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err_msg})
+			return
+		}
+
 		if s_start == "" {
 			s_start = "-1"
 		}
@@ -535,7 +785,7 @@ func getHandlerLookupArticles(cfg *config.Feed, g *gwyneth.Gwyneth) func(*gin.Co
 			src_ids = append(src_ids, src_id)
 		}
 
-		as, err := g.LookupArticles(title, body, src_ids, start, end, limit)
+		as, err := g.LookupArticles(title, body, author, category, src_ids, start, end, limit)
 		if err != nil {
 			err_msg := fmt.Sprintf("lookup failed: %s", err)
 			c.JSON(http.StatusBadRequest, gin.H{"error": err_msg})
@@ -1023,7 +1273,7 @@ func getHandlerAddFilter(g *gwyneth.Gwyneth) func(*gin.Context) {
 		}
 
 		added_f, err := g.AddFilter(f.Title.Value, f.Title.IsRegex,
-									f.Body.Value, f.Body.IsRegex, action_id)
+									f.Body.Value, f.Body.IsRegex, f.OnUpdate, action_id)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -1035,7 +1285,11 @@ func getHandlerAddFilter(g *gwyneth.Gwyneth) func(*gin.Context) {
 
 func getHandlerUpdateFilter(g *gwyneth.Gwyneth) func(*gin.Context) {
 	return func(c *gin.Context) {
-		var f external.Filter
+		var f struct {
+			Id       string           `json:"id"`
+			OnUpdate *bool            `json:"on_update"`
+			Action   *external.Action `json:"action"`
+		}
 		if err := c.ShouldBindJSON(&f); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -1046,16 +1300,31 @@ func getHandlerUpdateFilter(g *gwyneth.Gwyneth) func(*gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		action_id, err := model.ParseStringId(f.Action.Id)
+
+		updated_f, err := g.GetFilter(id)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if f.Action != nil {
+			action_id, err := model.ParseStringId(f.Action.Id)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 
-		updated_f, err := g.UpdateFilterAction(id, action_id)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
+			updated_f, err = g.UpdateFilterAction(id, action_id)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if f.OnUpdate != nil {
+			updated_f, err = g.UpdateFilterOnUpdate(id, *f.OnUpdate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 		}
 
 		c.JSON(http.StatusOK, updated_f.ConvertExternal())
@@ -1106,11 +1375,121 @@ func getHandlerGetSource(g *gwyneth.Gwyneth) func(*gin.Context) {
 		for _, st := range sts {
 			ext_src.Status = append(ext_src.Status, st.ConvertExternal())
 		}
+		if next, ok := g.GetSourceNextFetch(id); ok {
+			ext_src.NextFetch = next.Unix()
+		}
+		if opt, err := g.GetSourceOption(id); err == nil {
+			ext_src.Option = opt.ConvertExternal()
+		}
+		if state, err := g.GetSourceState(id); err == nil {
+			ext_src.Failures = state.Failures
+			if state.Quarantine != "" {
+				ext_src.Quarantine = &external.Quarantine{
+					Reason: state.Quarantine,
+					Timestamp: state.Quarantined,
+				}
+			}
+		}
 
 		c.IndentedJSON(http.StatusOK, ext_src)
 	}
 }
 
+func getHandlerVerifyWebSub(g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		id, err := model.ParseStringId(c.Param("id"))
+		if err != nil {
+			c.String(http.StatusNotFound, err.Error())
+			return
+		}
+
+		lease, _ := strconv.Atoi(c.Query("hub.lease_seconds"))
+		challenge, err := g.VerifyWebSub(id, c.Query("hub.mode"), c.Query("hub.topic"), c.Query("hub.challenge"), lease)
+		if err != nil {
+			c.String(http.StatusNotFound, err.Error())
+			return
+		}
+		c.String(http.StatusOK, challenge)
+	}
+}
+
+func getHandlerReceiveWebSub(cfg *config.WebSub, g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		id, err := model.ParseStringId(c.Param("id"))
+		if err != nil {
+			c.String(http.StatusNotFound, err.Error())
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.MaxSize)
+		body, err := c.GetRawData()
+		if err != nil {
+			var max_err *http.MaxBytesError
+			if errors.As(err, &max_err) {
+				c.String(http.StatusRequestEntityTooLarge, fmt.Sprintf("the body is larger than %d bytes", max_err.Limit))
+				return
+			}
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := g.ReceiveWebSub(id, c.GetHeader("X-Hub-Signature"), body); err != nil {
+			c.String(http.StatusNotFound, err.Error())
+			return
+		}
+		c.Status(http.StatusAccepted)
+	}
+}
+
+func getHandlerReceiveHook(cfg *config.Http, g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		id, err := model.ParseStringId(c.Param("source_id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.HookMaxSize)
+		body, err := c.GetRawData()
+		if err != nil {
+			var max_err *http.MaxBytesError
+			if errors.As(err, &max_err) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("the body is larger than %d bytes", max_err.Limit)})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		token := c.GetHeader("X-Gwyneth-Token")
+		if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			token = bearer
+		}
+		req := &gwyneth.HookRequest{
+			Token: token,
+			Signature: []string{c.GetHeader("X-Hub-Signature-256"), c.GetHeader("X-Hub-Signature")},
+		}
+
+		n, err := g.ReceiveHook(id, req, body)
+		if err != nil {
+			switch err {
+			case gwyneth.ErrHookNotFound:
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case gwyneth.ErrHookUnauthorized:
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			case gwyneth.ErrHookNotRecorded:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "success",
+			"articles": n,
+		})
+	}
+}
+
 func getHandlerPauseSource(g *gwyneth.Gwyneth) func(*gin.Context) {
 	return func(c *gin.Context) {
 		id_base := c.Param("id")
@@ -1148,6 +1527,136 @@ func getHandlerResumeSource(g *gwyneth.Gwyneth) func(*gin.Context) {
 	}
 }
 
+func getHandlerUpdateSourceOption(g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		id_base := c.Param("id")
+		id, err := model.ParseStringId(id_base)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var opt external.SourceOption
+		if err := c.ShouldBindJSON(&opt); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		slog.Debug("UpdateSourceOption: request is '%v'", opt)
+
+		new_opt := model.ImportExternalSourceOption(&opt)
+		if err := g.UpdateSourceOption(id, new_opt); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusOK, new_opt.ConvertExternal())
+	}
+}
+
+func getHandlerFetchSource(g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		id_base := c.Param("id")
+		id, err := model.ParseStringId(id_base)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		job, err := g.FetchSource(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusAccepted, job.ConvertExternal())
+	}
+}
+
+func getHandlerBackfillSource(g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		id_base := c.Param("id")
+		id, err := model.ParseStringId(id_base)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// the body is optional.
+		var req external.Backfill
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		job, err := g.BackfillSource(id, req.Limit, req.Actions)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.IndentedJSON(http.StatusAccepted, job.ConvertExternal())
+	}
+}
+
+func getHandlerGetFetchJob(g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		id_base := c.Param("id")
+		id, err := model.ParseStringId(id_base)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		job_id_base := c.Param("job_id")
+		job_id, err := model.ParseStringId(job_id_base)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		job, err := g.GetFetchJob(job_id)
+		if err != nil || job.SrcId.String() != id.String() {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("fetch job is not exist: '%s'", job_id_base)})
+			return
+		}
+		c.IndentedJSON(http.StatusOK, job.ConvertExternal())
+	}
+}
+
+func getHandlerGetSourceHistory(g *gwyneth.Gwyneth) func(*gin.Context) {
+	return func(c *gin.Context) {
+		id_base := c.Param("id")
+		id, err := model.ParseStringId(id_base)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		s_since := c.DefaultQuery("since", "0")
+		s_limit := c.DefaultQuery("limit", "100")
+		since, err := strconv.ParseInt(s_since, 10, 64)
+		if err != nil {
+			err_msg := fmt.Sprintf("unkown fmt the parameter of since('%s'): %s", s_since, err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err_msg})
+			return
+		}
+		limit, err := strconv.ParseInt(s_limit, 10, 64)
+		if err != nil {
+			err_msg := fmt.Sprintf("unkown fmt the parameter of limit('%s'): %s", s_limit, err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err_msg})
+			return
+		}
+
+		logs, err := g.GetFetchLogs(id, since, limit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ret_logs := []*external.FetchLog{}
+		for _, l := range logs {
+			ret_logs = append(ret_logs, l.ConvertExternal())
+		}
+		c.IndentedJSON(http.StatusOK, ret_logs)
+	}
+}
+
 func getHandlerReFilter(g *gwyneth.Gwyneth) func(*gin.Context) {
 	return func(c *gin.Context) {
 		id_base := c.Param("id")
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"net/url"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	DEDUP_AUTO    = "auto"    // the guid, or the canonical link for an article without guid.
+	DEDUP_GUID    = "guid"    // the guid. the link is ignored.
+	DEDUP_LINK    = "link"    // the canonical link. the guid is ignored.
+	DEDUP_CONTENT = "content" // the title, the body and the link like as the old versions.
+)
+
+var (
+	DEDUP_STRATEGIES = []string{DEDUP_AUTO, DEDUP_GUID, DEDUP_LINK, DEDUP_CONTENT}
+
+	// TRACKING_PARAMS are the query parameters which are removed from a link. a name with '*' is a prefix.
+	TRACKING_PARAMS = []string{"utm_*", "fbclid", "gclid", "mc_cid", "mc_eid", "yclid", "_hsenc", "_hsmi"}
+)
+
+func ParseDedup(s string) (string, error) {
+	if s == "" {
+		return DEDUP_AUTO, nil
+	}
+	for _, strategy := range DEDUP_STRATEGIES {
+		if s == strategy {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown dedup strategy: '%s'", s)
+}
+
+// DedupKeys returns the hashes which identify the article in the source. The first one is the key of the article,
+// and the others are the keys which an article recorded before has, e.g. the link of an article which had no guid.
+// An article without both of the guid and the link is identified by its content.
+// The link of an article with the guid is not a key if it has a fragment, because the fragment can be the only difference
+// between the articles, e.g. the anchors in a scraped page.
+func DedupKeys(strategy string, title string, body string, link string, guid string) []string {
+	keys := []string{}
+	guid = strings.TrimSpace(guid)
+	c_link := CanonicalLink(link)
+
+	switch strategy {
+	case DEDUP_GUID:
+		if guid != "" {
+			keys = append(keys, hashKey("guid", guid))
+		}
+	case DEDUP_LINK:
+		if c_link != "" {
+			keys = append(keys, hashKey("link", c_link))
+		}
+	case DEDUP_CONTENT:
+	default:
+		if guid != "" {
+			keys = append(keys, hashKey("guid", guid))
+		}
+		if c_link != "" && (guid == "" || !strings.Contains(link, "#")) {
+			keys = append(keys, hashKey("link", c_link))
+		}
+	}
+
+	if len(keys) < 1 {
+		keys = append(keys, hashKey("content", title, body, link))
+	}
+	return keys
+}
+
+func hashKey(kind string, vals ...string) string {
+	h := sha256.New()
+	h.Write([]byte(kind))
+	for _, val := range vals {
+		h.Write([]byte{0})
+		h.Write([]byte(val))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CanonicalLink normalizes a link to compare. The scheme and the host are lowercased, the default port,
+// the fragment and the tracking parameters are removed, the parameters are sorted and the trailing slash is removed.
+func CanonicalLink(link string) string {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return ""
+	}
+
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	switch {
+	case u.Scheme == "http" && strings.HasSuffix(u.Host, ":80"):
+		u.Host = strings.TrimSuffix(u.Host, ":80")
+	case u.Scheme == "https" && strings.HasSuffix(u.Host, ":443"):
+		u.Host = strings.TrimSuffix(u.Host, ":443")
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+
+	q := u.Query()
+	for name, _ := range q {
+		if isTrackingParam(name) {
+			q.Del(name)
+		}
+	}
+	u.RawQuery = q.Encode() // the parameters are sorted by the name.
+	return u.String()
+}
+
+func isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, param := range TRACKING_PARAMS {
+		if strings.HasSuffix(param, "*") {
+			if strings.HasPrefix(name, strings.TrimSuffix(param, "*")) {
+				return true
+			}
+			continue
+		}
+		if name == param {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestCanonicalLink(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"", ""},
+		{"  ", ""},
+		{"HTTP://Example.COM:80/a/", "http://example.com/a"},
+		{"https://example.com:443/a?b=2&a=1", "https://example.com/a?a=1&b=2"},
+		{"https://example.com/a?utm_source=x&fbclid=y&id=1", "https://example.com/a?id=1"},
+		{"https://example.com/a#comments", "https://example.com/a"},
+		{"mid:abc@example.com", "mid:abc@example.com"},
+		{"not a url", "not a url"},
+	}
+	for _, tt := range tests {
+		if got := CanonicalLink(tt.link); got != tt.want {
+			t.Errorf("CanonicalLink(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestDedupKeys(t *testing.T) {
+	same := func(a []string, b []string) bool {
+		for _, key := range b {
+			if key == a[0] {
+				return true
+			}
+		}
+		return false
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		a        [4]string // title, body, link, guid
+		b        [4]string
+		same     bool
+	}{
+		{"same guid", DEDUP_AUTO,
+			[4]string{"t1", "b1", "https://example.com/1", "g1"},
+			[4]string{"t2", "b2", "https://example.com/2", "g1"}, true},
+		{"same link with tracking params", DEDUP_AUTO,
+			[4]string{"t1", "b1", "https://example.com/1", ""},
+			[4]string{"t2", "b2", "https://example.com/1/?utm_source=x", ""}, true},
+		{"guid added later", DEDUP_AUTO,
+			[4]string{"t1", "b1", "https://example.com/1", ""},
+			[4]string{"t1", "b1", "https://example.com/1", "g1"}, true},
+		{"different guid and same link", DEDUP_AUTO,
+			[4]string{"t1", "b1", "https://example.com/1", "g1"},
+			[4]string{"t2", "b2", "https://example.com/1", "g2"}, false},
+		{"jsonapi elements without link", DEDUP_AUTO,
+			[4]string{"t1", "b1", "", "1"},
+			[4]string{"t2", "b2", "", "2"}, false},
+		{"scraped items without link", DEDUP_AUTO,
+			[4]string{"t1", "b1", "", ""},
+			[4]string{"t2", "b2", "", ""}, false},
+		{"scraped anchors in a page", DEDUP_AUTO,
+			[4]string{"t1", "b1", "https://example.com/list#1", "https://example.com/list#1"},
+			[4]string{"t2", "b2", "https://example.com/list#2", "https://example.com/list#2"}, false},
+		{"anchor after an article of the page", DEDUP_AUTO,
+			[4]string{"t1", "b1", "https://example.com/list", ""},
+			[4]string{"t2", "b2", "https://example.com/list#2", "https://example.com/list#2"}, false},
+		{"same content", DEDUP_CONTENT,
+			[4]string{"t1", "b1", "https://example.com/1", "g1"},
+			[4]string{"t1", "b1", "https://example.com/1", "g2"}, true},
+		{"guid strategy ignores link", DEDUP_GUID,
+			[4]string{"t1", "b1", "https://example.com/1", "g1"},
+			[4]string{"t1", "b1", "https://example.com/1", "g2"}, false},
+		{"link strategy ignores guid", DEDUP_LINK,
+			[4]string{"t1", "b1", "https://example.com/1", "g1"},
+			[4]string{"t2", "b2", "https://example.com/1", "g2"}, true},
+	}
+	for _, tt := range tests {
+		a := DedupKeys(tt.strategy, tt.a[0], tt.a[1], tt.a[2], tt.a[3])
+		b := DedupKeys(tt.strategy, tt.b[0], tt.b[1], tt.b[2], tt.b[3])
+		if got := same(a, b); got != tt.same {
+			t.Errorf("%s: same = %v, want %v", tt.name, got, tt.same)
+		}
+	}
+}
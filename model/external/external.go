@@ -8,23 +8,60 @@ type SourceType struct {
 }
 
 type Source struct {
-	Id    string      `json:"id"`
-	Title string      `json:"title"`
-	Type  *SourceType `json:"type"`
-	Value string      `json:"value"`
-	Pause bool        `json:"pause"`
+	Id       string        `json:"id"`
+	Title    string        `json:"title"`
+	Type     *SourceType   `json:"type"`
+	Value    string        `json:"value"`
+	Pause    bool          `json:"pause"`
+	Interval int           `json:"interval"`
+	Group    string        `json:"group"`
+	Option   *SourceOption `json:"option,omitempty"`
 
-	Status []*Status  `json:"status"`
+	Status     []*Status   `json:"status"`
+	NextFetch  int64       `json:"next_fetch,omitempty"`
+	Failures   int         `json:"failures,omitempty"`
+	Quarantine *Quarantine `json:"quarantine,omitempty"`
 }
 
 type Article struct {
-	Id         string  `json:"id"`
-	Src        *Source `json:"src"`
-	Title      string  `json:"title"`
-	Body       string  `json:"body"`
-	Link       string  `json:"link"`
-	Timestamp  int     `json:"timestamp"`
-	Raw        string  `json:"raw"`
+	Id         string       `json:"id"`
+	Src        *Source      `json:"src"`
+	Title      string       `json:"title"`
+	Body       string       `json:"body"`
+	Link       string       `json:"link"`
+	Timestamp  int          `json:"timestamp"`
+	Raw        string       `json:"raw"`
+	FullText   string       `json:"full_text,omitempty"`
+	Revision   int          `json:"revision,omitempty"`
+
+	Author     string       `json:"author,omitempty"`
+	Categories []string     `json:"categories,omitempty"`
+	Guid       string       `json:"guid,omitempty"`
+	Content    string       `json:"content,omitempty"`
+	Enclosures []*Enclosure `json:"enclosures,omitempty"`
+	Image      string       `json:"image,omitempty"`
+}
+
+type Enclosure struct {
+	Url    string `json:"url"`
+	Type   string `json:"type,omitempty"`
+	Length int64  `json:"length,omitempty"`
+}
+
+type ArticleRevision struct {
+	Revision  int          `json:"revision"`
+	Title     string       `json:"title"`
+	Body      string       `json:"body"`
+	Content   string       `json:"content,omitempty"`
+	Timestamp int64        `json:"timestamp"`
+	Diff      *ArticleDiff `json:"diff,omitempty"`
+}
+
+// ArticleDiff is the unified diffs from the previous version.
+type ArticleDiff struct {
+	Title   string `json:"title,omitempty"`
+	Body    string `json:"body,omitempty"`
+	Content string `json:"content,omitempty"`
 }
 
 type Action struct {
@@ -38,6 +75,7 @@ type Filter struct {
 
 	Title         *FilterValue `json:"title"`
 	Body          *FilterValue `json:"body"`
+	OnUpdate      bool         `json:"on_update"`
 
 	Action        *Action      `json:"action"`
 }
@@ -48,7 +86,75 @@ type FilterValue struct {
 }
 
 type Status struct {
-	Unixtime  int    `json:"timestamp"`
-	IsSuccess bool   `json:"success"`
-	Log       string `json:"log"`
+	Unixtime    int    `json:"timestamp"`
+	IsSuccess   bool   `json:"success"`
+	IsPermanent bool   `json:"permanent,omitempty"`
+	Log         string `json:"log"`
+}
+
+// FetchLog is a collection of a source. The duration is in milliseconds.
+type FetchLog struct {
+	Timestamp   int64  `json:"timestamp"`
+	Duration    int64  `json:"duration"`
+	HttpStatus  int    `json:"http_status,omitempty"`
+	Bytes       int64  `json:"bytes"`
+	Items       int    `json:"items"`
+	NewArticles int    `json:"new_articles"`
+	IsSuccess   bool   `json:"success"`
+	IsPermanent bool   `json:"permanent,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type Quarantine struct {
+	Reason    string `json:"reason"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+type SourceOption struct {
+	FullText bool        `json:"full_text"`
+	Dedup    string      `json:"dedup"`
+	Http     *HttpOption `json:"http,omitempty"`
+	Hook     *HookOption `json:"hook,omitempty"`
+}
+
+type HookOption struct {
+	Token    string        `json:"token"`
+	Hmac     bool          `json:"hmac,omitempty"`
+	Template *HookTemplate `json:"template,omitempty"`
+}
+
+type HookTemplate struct {
+	Items           string `json:"items,omitempty"`
+	Id              string `json:"id,omitempty"`
+	Title           string `json:"title"`
+	Body            string `json:"body,omitempty"`
+	Link            string `json:"link,omitempty"`
+	Timestamp       string `json:"timestamp,omitempty"`
+	TimestampFormat string `json:"timestamp_format,omitempty"`
+}
+
+type HttpOption struct {
+	Headers    map[string]string `json:"headers,omitempty"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	Auth       string            `json:"auth,omitempty"`
+	Username   string            `json:"username,omitempty"`
+	Credential string            `json:"credential,omitempty"`
+	Proxy      string            `json:"proxy,omitempty"`
+	Timeout    int               `json:"timeout,omitempty"`
+	SkipVerify bool              `json:"skip_verify,omitempty"`
+	CA         string            `json:"ca,omitempty"`
+}
+
+type Backfill struct {
+	Limit   int  `json:"limit"`
+	Actions bool `json:"actions"`
+}
+
+type FetchJob struct {
+	Id        string  `json:"id"`
+	SrcId     string  `json:"src_id"`
+	State     string  `json:"state"`
+	Requested int64   `json:"requested"`
+	Started   int64   `json:"started,omitempty"`
+	Status    *Status `json:"status,omitempty"`
 }
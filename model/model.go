@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"time"
 )
 
 import (
@@ -9,6 +10,7 @@ import (
 )
 
 import (
+	"github.com/hinoshiba/gwyneth/diff"
 	"github.com/hinoshiba/gwyneth/model/external"
 )
 
@@ -122,15 +124,19 @@ type Source struct {
 	src_type *SourceType
 	val      string
 	pause    bool
+	interval int
+	group    string
 }
 
-func NewSource(id *Id, title string, src_type *SourceType, val string, pause bool) *Source {
+func NewSource(id *Id, title string, src_type *SourceType, val string, pause bool, interval int, group string) *Source {
 	return &Source {
 		id: id,
 		title: title,
 		src_type: src_type,
 		val: val,
 		pause: pause,
+		interval: interval,
+		group: group,
 	}
 }
 
@@ -154,6 +160,14 @@ func (self *Source) IsPause() bool {
 	return self.pause
 }
 
+func (self *Source) Interval() int {
+	return self.interval
+}
+
+func (self *Source) Group() string {
+	return self.group
+}
+
 func (self *Source) ConvertExternal() *external.Source {
 	return &external.Source {
 		Id: self.id.String(),
@@ -161,6 +175,8 @@ func (self *Source) ConvertExternal() *external.Source {
 		Type: self.src_type.ConvertExternal(),
 		Value: self.val,
 		Pause: self.pause,
+		Interval: self.interval,
+		Group: self.group,
 
 		Status: []*external.Status{},
 	}
@@ -182,6 +198,8 @@ func ImportExternalSource(ex_src *external.Source) (*Source, error) {
 		src_type: src_type,
 		val: ex_src.Value,
 		pause: ex_src.Pause,
+		interval: ex_src.Interval,
+		group: ex_src.Group,
 	}, nil
 }
 
@@ -193,6 +211,26 @@ type Article struct {
 	link  string
 	utime int64
 	raw   string
+
+	meta      *ArticleMeta
+	full_text string
+	revision  int
+}
+
+// ArticleMeta is the information of an article which is given by the source optionally.
+type ArticleMeta struct {
+	Author     string
+	Categories []string
+	Guid       string
+	Content    string       // the full content, while the body is the description.
+	Enclosures []*Enclosure
+	Image      string       // the url of the lead image.
+}
+
+type Enclosure struct {
+	Url    string
+	Type   string
+	Length int64
 }
 
 func NewArticle(id *Id, src *Source, title string, body string, link string, utime int64, raw string) *Article {
@@ -204,6 +242,7 @@ func NewArticle(id *Id, src *Source, title string, body string, link string, uti
 		link: link,
 		utime: utime,
 		raw: raw,
+		meta: &ArticleMeta{},
 	}
 }
 
@@ -235,6 +274,41 @@ func (self *Article) Raw() string {
 	return self.raw
 }
 
+func (self *Article) Meta() *ArticleMeta {
+	return self.meta
+}
+
+func (self *Article) WithMeta(meta *ArticleMeta) *Article {
+	if meta == nil {
+		meta = &ArticleMeta{}
+	}
+	artcl := *self
+	artcl.meta = meta
+	return &artcl
+}
+
+// FullText is the main text of the linked page. It is empty if it is not extracted.
+func (self *Article) FullText() string {
+	return self.full_text
+}
+
+func (self *Article) WithFullText(text string) *Article {
+	artcl := *self
+	artcl.full_text = text
+	return &artcl
+}
+
+// Revision is the number of the updates of the article by the source. It is set only when the article is updated.
+func (self *Article) Revision() int {
+	return self.revision
+}
+
+func (self *Article) WithRevision(revision int) *Article {
+	artcl := *self
+	artcl.revision = revision
+	return &artcl
+}
+
 func (self *Article) ConvertExternal() *external.Article {
 	return &external.Article{
 		Id: self.id.String(),
@@ -244,6 +318,15 @@ func (self *Article) ConvertExternal() *external.Article {
 		Link: self.link,
 		Timestamp: int(self.utime),
 		Raw: self.raw,
+		FullText: self.full_text,
+		Revision: self.revision,
+
+		Author: self.meta.Author,
+		Categories: self.meta.Categories,
+		Guid: self.meta.Guid,
+		Content: self.meta.Content,
+		Enclosures: ConvertExternalEnclosures(self.meta.Enclosures),
+		Image: self.meta.Image,
 	}
 }
 
@@ -265,19 +348,450 @@ func ImportExternalArticle(ex_article *external.Article) (*Article, error) {
 		link: ex_article.Link,
 		utime: int64(ex_article.Timestamp),
 		raw: ex_article.Raw,
+		meta: ImportExternalArticleMeta(ex_article),
+		full_text: ex_article.FullText,
 	}, nil
 }
 
+func ImportExternalArticleMeta(ex_article *external.Article) *ArticleMeta {
+	if ex_article == nil {
+		return &ArticleMeta{}
+	}
+	meta := &ArticleMeta{
+		Author: ex_article.Author,
+		Categories: ex_article.Categories,
+		Guid: ex_article.Guid,
+		Content: ex_article.Content,
+		Image: ex_article.Image,
+		Enclosures: ImportExternalEnclosures(ex_article.Enclosures),
+	}
+	return meta
+}
+
+func ImportExternalEnclosures(ex_encs []*external.Enclosure) []*Enclosure {
+	var encs []*Enclosure
+	for _, ex_enc := range ex_encs {
+		if ex_enc == nil {
+			continue
+		}
+		encs = append(encs, &Enclosure{
+			Url: ex_enc.Url,
+			Type: ex_enc.Type,
+			Length: ex_enc.Length,
+		})
+	}
+	return encs
+}
+
+func ConvertExternalEnclosures(encs []*Enclosure) []*external.Enclosure {
+	if len(encs) < 1 {
+		return nil
+	}
+	ex_encs := make([]*external.Enclosure, 0, len(encs))
+	for _, enc := range encs {
+		if enc == nil {
+			continue
+		}
+		ex_encs = append(ex_encs, &external.Enclosure{
+			Url: enc.Url,
+			Type: enc.Type,
+			Length: enc.Length,
+		})
+	}
+	return ex_encs
+}
+
 type Status struct {
-	Unixtime  int
-	IsSuccess bool
-	Log       string
+	Unixtime    int
+	IsSuccess   bool
+	IsPermanent bool // the failure is not fixed by retrying.
+	Log         string
 }
 
 func (self *Status) ConvertExternal() *external.Status {
 	return &external.Status{
 		Unixtime: self.Unixtime,
 		IsSuccess: self.IsSuccess,
+		IsPermanent: self.IsPermanent,
 		Log: self.Log,
 	}
 }
+
+// FetchLog is the record of a collection of a source. A content pushed by the source is also recorded.
+type FetchLog struct {
+	Unixtime    int64 // when the collection is started.
+	Duration    time.Duration
+	HttpStatus  int   // the status of the last response. it is 0 if the source is not read over HTTP.
+	Bytes       int64 // the size of the read bodies.
+	Items       int   // count of the articles which the source has.
+	NewArticles int
+	IsSuccess   bool
+	IsPermanent bool
+	Log         string
+}
+
+// Status returns the log as a status of the end of the collection.
+func (self *FetchLog) Status() *Status {
+	return &Status{
+		Unixtime: int(time.Unix(self.Unixtime, 0).Add(self.Duration).Unix()),
+		IsSuccess: self.IsSuccess,
+		IsPermanent: self.IsPermanent,
+		Log: self.Log,
+	}
+}
+
+func (self *FetchLog) ConvertExternal() *external.FetchLog {
+	ex_log := &external.FetchLog{
+		Timestamp: self.Unixtime,
+		Duration: self.Duration.Milliseconds(),
+		HttpStatus: self.HttpStatus,
+		Bytes: self.Bytes,
+		Items: self.Items,
+		NewArticles: self.NewArticles,
+		IsSuccess: self.IsSuccess,
+		IsPermanent: self.IsPermanent,
+	}
+	if !self.IsSuccess {
+		ex_log.Error = self.Log
+	}
+	return ex_log
+}
+
+// ArticleRevision is a version of an article. The first version is 0.
+type ArticleRevision struct {
+	Revision int
+	Title    string
+	Body     string
+	Content  string
+	Unixtime int64 // when the version is recorded. the first version has the timestamp of the article.
+}
+
+// ConvertExternalRevisions converts the versions in order, with the diffs from the previous version.
+func ConvertExternalRevisions(revs []*ArticleRevision) []*external.ArticleRevision {
+	ex_revs := make([]*external.ArticleRevision, 0, len(revs))
+	for i, rev := range revs {
+		ex_rev := &external.ArticleRevision{
+			Revision: rev.Revision,
+			Title: rev.Title,
+			Body: rev.Body,
+			Content: rev.Content,
+			Timestamp: rev.Unixtime,
+		}
+		if i > 0 {
+			prev := revs[i - 1]
+			ex_rev.Diff = &external.ArticleDiff{
+				Title: diff.Unified(prev.Title, rev.Title),
+				Body: diff.Unified(prev.Body, rev.Body),
+				Content: diff.Unified(prev.Content, rev.Content),
+			}
+		}
+		ex_revs = append(ex_revs, ex_rev)
+	}
+	return ex_revs
+}
+
+// SourceOption is the behavior of the collection which is chosen per source.
+type SourceOption struct {
+	FullText bool        // extracts the main text of the linked page of a new article.
+	Dedup    string      // the strategy to identify an article which is already recorded. see DedupKeys.
+	Http     *HttpOption // nil if the source is read with the default settings.
+	Hook     *HookOption // nil if the source does not accept the webhook.
+}
+
+func (self *SourceOption) ConvertExternal() *external.SourceOption {
+	return &external.SourceOption{
+		FullText: self.FullText,
+		Dedup: self.Dedup,
+		Http: self.Http.ConvertExternal(),
+		Hook: self.Hook.ConvertExternal(),
+	}
+}
+
+func ImportExternalSourceOption(ex_opt *external.SourceOption) *SourceOption {
+	return &SourceOption{
+		FullText: ex_opt.FullText,
+		Dedup: ex_opt.Dedup,
+		Http: ImportExternalHttpOption(ex_opt.Http),
+		Hook: ImportExternalHookOption(ex_opt.Hook),
+	}
+}
+
+// HookOption is the settings of the webhook of a source.
+type HookOption struct {
+	Token    string        // the token of the requests, or the key of the signature.
+	Hmac     bool          // requires the HMAC signature of the body instead of the token.
+	Template *HookTemplate // maps an arbitrary json to the articles. the body is an article if it is nil.
+}
+
+// HookTemplate is the paths of the values of the articles in a json like as the jsonapi source.
+// Items selects the elements from the body, and the other paths are evaluated in each element.
+type HookTemplate struct {
+	Items           string
+	Id              string
+	Title           string
+	Body            string
+	Link            string
+	Timestamp       string
+	TimestampFormat string
+}
+
+func (self *HookOption) ConvertExternal() *external.HookOption {
+	if self == nil {
+		return nil
+	}
+	ex_opt := &external.HookOption{
+		Token: self.Token,
+		Hmac: self.Hmac,
+	}
+	if self.Template != nil {
+		ex_opt.Template = &external.HookTemplate{
+			Items: self.Template.Items,
+			Id: self.Template.Id,
+			Title: self.Template.Title,
+			Body: self.Template.Body,
+			Link: self.Template.Link,
+			Timestamp: self.Template.Timestamp,
+			TimestampFormat: self.Template.TimestampFormat,
+		}
+	}
+	return ex_opt
+}
+
+func ImportExternalHookOption(ex_opt *external.HookOption) *HookOption {
+	if ex_opt == nil {
+		return nil
+	}
+	opt := &HookOption{
+		Token: ex_opt.Token,
+		Hmac: ex_opt.Hmac,
+	}
+	if ex_opt.Template != nil {
+		opt.Template = &HookTemplate{
+			Items: ex_opt.Template.Items,
+			Id: ex_opt.Template.Id,
+			Title: ex_opt.Template.Title,
+			Body: ex_opt.Template.Body,
+			Link: ex_opt.Template.Link,
+			Timestamp: ex_opt.Template.Timestamp,
+			TimestampFormat: ex_opt.Template.TimestampFormat,
+		}
+	}
+	return opt
+}
+
+const (
+	HTTP_AUTH_NONE   = ""
+	HTTP_AUTH_BASIC  = "basic"
+	HTTP_AUTH_BEARER = "bearer"
+)
+
+// HttpOption is the settings of the requests to a source. The credential is not stored in it,
+// it has the name of the secret in the secrets store instead.
+type HttpOption struct {
+	Headers    map[string]string
+	UserAgent  string
+	Auth       string // HTTP_AUTH_NONE, HTTP_AUTH_BASIC or HTTP_AUTH_BEARER.
+	Username   string // the user of the basic authentication.
+	Credential string // the name of the secret which is the password or the token.
+	Proxy      string // the url of the proxy. the proxy of the environment is used if it is empty.
+	Timeout    int    // seconds. 0 is no limit.
+	SkipVerify bool   // skips the verification of the certificate of the server.
+	CA         string // PEM of the certificates which are trusted in addition to the system's.
+}
+
+func ParseHttpAuth(s string) (string, error) {
+	switch s {
+	case HTTP_AUTH_NONE, HTTP_AUTH_BASIC, HTTP_AUTH_BEARER:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown http auth: '%s'", s)
+}
+
+func (self *HttpOption) ConvertExternal() *external.HttpOption {
+	if self == nil {
+		return nil
+	}
+	return &external.HttpOption{
+		Headers: self.Headers,
+		UserAgent: self.UserAgent,
+		Auth: self.Auth,
+		Username: self.Username,
+		Credential: self.Credential,
+		Proxy: self.Proxy,
+		Timeout: self.Timeout,
+		SkipVerify: self.SkipVerify,
+		CA: self.CA,
+	}
+}
+
+func ImportExternalHttpOption(ex_opt *external.HttpOption) *HttpOption {
+	if ex_opt == nil {
+		return nil
+	}
+	return &HttpOption{
+		Headers: ex_opt.Headers,
+		UserAgent: ex_opt.UserAgent,
+		Auth: ex_opt.Auth,
+		Username: ex_opt.Username,
+		Credential: ex_opt.Credential,
+		Proxy: ex_opt.Proxy,
+		Timeout: ex_opt.Timeout,
+		SkipVerify: ex_opt.SkipVerify,
+		CA: ex_opt.CA,
+	}
+}
+
+// FetchJob is a collection of a source which is requested on demand.
+type FetchJob struct {
+	Id        *Id
+	SrcId     *Id
+	Requested time.Time
+	Started   time.Time
+	Status    *Status
+}
+
+const (
+	FETCH_JOB_QUEUED  = "queued"
+	FETCH_JOB_RUNNING = "running"
+	FETCH_JOB_DONE    = "done"
+)
+
+func (self *FetchJob) State() string {
+	if self.Status != nil {
+		return FETCH_JOB_DONE
+	}
+	if !self.Started.IsZero() {
+		return FETCH_JOB_RUNNING
+	}
+	return FETCH_JOB_QUEUED
+}
+
+func (self *FetchJob) ConvertExternal() *external.FetchJob {
+	ex_job := &external.FetchJob{
+		Id: self.Id.String(),
+		SrcId: self.SrcId.String(),
+		State: self.State(),
+		Requested: self.Requested.Unix(),
+	}
+	if !self.Started.IsZero() {
+		ex_job.Started = self.Started.Unix()
+	}
+	if self.Status != nil {
+		ex_job.Status = self.Status.ConvertExternal()
+	}
+	return ex_job
+}
+
+type SourceState struct {
+	ETag         string
+	LastModified string
+
+	TTL          int    // seconds. the update period which is declared by the source.
+	SkipHours    uint32 // bits of the hours(UTC) which the source asks not to be read.
+	SkipDays     uint8  // bits of the weekdays(UTC) which the source asks not to be read.
+	RetryAfter   int64
+	LastUpdate   int64  // unixtime of the newest article.
+	Idle         int    // count of the collections without a new article.
+	NextFetch    int64
+
+	Hub          string // the WebSub hub which is declared by the source.
+	Topic        string
+
+	Cursor       string // the position which the collector has read to.
+
+	Failures     int    // count of the consecutive failed collections.
+	Permanent    bool   // the last failure is permanent.
+	Quarantine   string // the reason why the source is paused automatically.
+	Quarantined  int64
+}
+
+func (self *SourceState) SetSkipHour(hour int) {
+	if hour < 0 || hour > 23 {
+		return
+	}
+	self.SkipHours |= 1 << uint(hour)
+}
+
+func (self *SourceState) SetSkipDay(day time.Weekday) {
+	if day < time.Sunday || day > time.Saturday {
+		return
+	}
+	self.SkipDays |= 1 << uint(day)
+}
+
+func (self *SourceState) IsSkip(t time.Time) bool {
+	t = t.UTC()
+	if self.SkipHours & (1 << uint(t.Hour())) != 0 {
+		return true
+	}
+	if self.SkipDays & (1 << uint(t.Weekday())) != 0 {
+		return true
+	}
+	return false
+}
+
+func (self *SourceState) Equal(st *SourceState) bool {
+	if st == nil {
+		return false
+	}
+	return *self == *st
+}
+
+func (self *SourceState) Copy() *SourceState {
+	st := *self
+	return &st
+}
+
+type Subscription struct {
+	src_id    *Id
+	hub       string
+	topic     string
+	secret    string
+	requested int64
+	lease_end int64
+	pending   bool // a subscription is requested and not verified by the hub yet.
+}
+
+func NewSubscription(src_id *Id, hub string, topic string, secret string, requested int64, lease_end int64, pending bool) *Subscription {
+	return &Subscription{
+		src_id: src_id,
+		hub: hub,
+		topic: topic,
+		secret: secret,
+		requested: requested,
+		lease_end: lease_end,
+		pending: pending,
+	}
+}
+
+func (self *Subscription) SrcId() *Id {
+	return self.src_id
+}
+
+func (self *Subscription) Hub() string {
+	return self.hub
+}
+
+func (self *Subscription) Topic() string {
+	return self.topic
+}
+
+func (self *Subscription) Secret() string {
+	return self.secret
+}
+
+func (self *Subscription) RequestedAt() int64 {
+	return self.requested
+}
+
+func (self *Subscription) LeaseEnd() int64 {
+	return self.lease_end
+}
+
+func (self *Subscription) IsPending() bool {
+	return self.pending
+}
+
+func (self *Subscription) IsActive(now time.Time) bool {
+	return self.lease_end > now.Unix()
+}
@@ -0,0 +1,104 @@
+package gwyneth
+
+import (
+	"io"
+	"fmt"
+	"time"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/slog"
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/opml"
+)
+
+// ImportOPML adds the feeds of the opml as rss sources. The feed whose url is
+// already a value of a source is skipped and returned as skipped.
+func (self *Gwyneth) ImportOPML(r io.Reader) ([]*model.Source, []string, error) {
+	o, err := opml.Parse(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	st, err := self.getSourceTypeByName(opml.TYPE_RSS)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	srcs, err := self.tv.GetSources()
+	if err != nil {
+		return nil, nil, err
+	}
+	known := make(map[string]struct{})
+	for _, src := range srcs {
+		known[src.Value()] = struct{}{}
+	}
+
+	added := []*model.Source{}
+	skipped := []string{}
+	for _, f := range o.Feeds() {
+		if f.Type != opml.TYPE_RSS {
+			skipped = append(skipped, f.Url)
+			continue
+		}
+		if _, ok := known[f.Url]; ok {
+			skipped = append(skipped, f.Url)
+			continue
+		}
+		known[f.Url] = struct{}{}
+
+		src, err := self.tv.AddSource(f.Title, st.Id(), f.Url, 0, f.Group)
+		if err != nil {
+			slog.Warn("failed: cannot import the source '%s': %s", f.Url, err)
+			skipped = append(skipped, f.Url)
+			continue
+		}
+		if f.Pause {
+			if err := self.tv.PauseSource(src.Id()); err != nil {
+				slog.Warn("failed: cannot pause the imported source '%s': %s", f.Url, err)
+			} else {
+				src, _ = self.tv.GetSource(src.Id())
+			}
+		}
+		added = append(added, src)
+	}
+
+	if len(added) > 0 {
+		self.new_src.Notice()
+	}
+	return added, skipped, nil
+}
+
+// ExportOPML writes all sources as an opml. The group of a source is the folder.
+func (self *Gwyneth) ExportOPML(w io.Writer) error {
+	srcs, err := self.tv.GetSources()
+	if err != nil {
+		return err
+	}
+
+	o := opml.New(self.cfg.Feed.Title)
+	o.Head.DateCreated = time.Now().Format(time.RFC1123Z)
+	for _, src := range srcs {
+		o.AddFeed(&opml.Feed{
+			Title: src.Title(),
+			Url: src.Value(),
+			Type: src.Type().Name(),
+			Group: src.Group(),
+			Pause: src.IsPause(),
+		})
+	}
+	return o.Render(w)
+}
+
+func (self *Gwyneth) getSourceTypeByName(name string) (*model.SourceType, error) {
+	sts, err := self.tv.GetSourceTypes()
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range sts {
+		if st.Name() == name && !st.IsUserCreate() {
+			return st, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot find the source type '%s'.", name)
+}
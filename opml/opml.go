@@ -0,0 +1,156 @@
+package opml
+
+import (
+	"io"
+	"fmt"
+	"strings"
+	"encoding/xml"
+)
+
+const (
+	VERSION = "2.0"
+
+	TYPE_RSS = "rss"
+)
+
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Body struct {
+	Outlines []*Outline `xml:"outline"`
+}
+
+type Outline struct {
+	Text     string     `xml:"text,attr"`
+	Title    string     `xml:"title,attr,omitempty"`
+	Type     string     `xml:"type,attr,omitempty"`
+	XmlUrl   string     `xml:"xmlUrl,attr,omitempty"`
+	HtmlUrl  string     `xml:"htmlUrl,attr,omitempty"`
+	Pause    string     `xml:"pause,attr,omitempty"`
+	Outlines []*Outline `xml:"outline"`
+}
+
+// Feed is a subscription of an outline with the folders which contain it.
+type Feed struct {
+	Title  string
+	Url    string
+	Type   string
+	Group  string
+	Pause  bool
+}
+
+func New(title string) *OPML {
+	return &OPML{
+		Version: VERSION,
+		Head: Head{Title: title},
+	}
+}
+
+func Parse(r io.Reader) (*OPML, error) {
+	var o OPML
+	if err := xml.NewDecoder(r).Decode(&o); err != nil {
+		return nil, fmt.Errorf("cannot parse the opml: %s", err)
+	}
+	return &o, nil
+}
+
+func (self *OPML) Render(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(self); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Feeds returns the outlines which have a xmlUrl. The group of the feed is the
+// path of the folders joined with '/'.
+func (self *OPML) Feeds() []*Feed {
+	var ret []*Feed
+	for _, o := range self.Body.Outlines {
+		ret = append(ret, o.feeds(nil)...)
+	}
+	return ret
+}
+
+func (self *Outline) feeds(folders []string) []*Feed {
+	if self.XmlUrl != "" {
+		title := self.Title
+		if title == "" {
+			title = self.Text
+		}
+		if title == "" {
+			title = self.XmlUrl
+		}
+		typ := strings.ToLower(self.Type)
+		if typ == "" || typ == "atom" {
+			typ = TYPE_RSS
+		}
+		return []*Feed{&Feed{
+			Title: title,
+			Url: strings.TrimSpace(self.XmlUrl),
+			Type: typ,
+			Group: strings.Join(folders, "/"),
+			Pause: strings.EqualFold(self.Pause, "true"),
+		}}
+	}
+
+	name := self.Text
+	if name == "" {
+		name = self.Title
+	}
+	if name != "" {
+		folders = append(folders[:len(folders):len(folders)], name)
+	}
+
+	var ret []*Feed
+	for _, o := range self.Outlines {
+		ret = append(ret, o.feeds(folders)...)
+	}
+	return ret
+}
+
+// AddFeed adds the feed into the folder of its group, creating the folders.
+func (self *OPML) AddFeed(f *Feed) {
+	outlines := &self.Body.Outlines
+	if f.Group != "" {
+		for _, name := range strings.Split(f.Group, "/") {
+			outlines = &folder(outlines, name).Outlines
+		}
+	}
+
+	o := &Outline{
+		Text: f.Title,
+		Title: f.Title,
+		Type: f.Type,
+		XmlUrl: f.Url,
+	}
+	if f.Pause {
+		o.Pause = "true"
+	}
+	*outlines = append(*outlines, o)
+}
+
+func folder(outlines *[]*Outline, name string) *Outline {
+	for _, o := range *outlines {
+		if o.XmlUrl == "" && o.Text == name {
+			return o
+		}
+	}
+	o := &Outline{Text: name, Title: name}
+	*outlines = append(*outlines, o)
+	return o
+}
@@ -0,0 +1,96 @@
+package gwyneth
+
+import (
+	"fmt"
+	"time"
+	"strings"
+	"unicode/utf8"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/collector"
+)
+
+const (
+	PREVIEW_TIMEOUT = 60 * time.Second
+)
+
+// Preview runs the collector of the source type once and returns the articles
+// without recording them. The warnings are the problems found in the articles.
+func (self *Gwyneth) Preview(src_type_id *model.Id, val string) ([]*model.Article, []string, error) {
+	st, err := self.tv.GetSourceType(src_type_id)
+	if err != nil {
+		return nil, nil, err
+	}
+	clctr, err := collector.Lookup(st)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	src := model.NewSource(model.NewId(nil), "preview", st, val, false, 0, "")
+	artcl_ch := make(chan *model.Article)
+	job := &collector.Job{
+		Logger: self.lm.GetCollectorsLogger(),
+		Src: src,
+		State: &model.SourceState{},
+		ArticleCh: artcl_ch,
+		DryRun: true,
+	}
+
+	done := make(chan []*model.Article)
+	go func() {
+		artcls := []*model.Article{}
+		for artcl := range artcl_ch {
+			artcls = append(artcls, model.NewArticle(model.NewId(nil), src,
+				artcl.Title(), artcl.Body(), artcl.Link(), artcl.Unixtime(), artcl.Raw()).WithMeta(artcl.Meta()))
+		}
+		done <- artcls
+	}()
+
+	msn := self.msn.New()
+	tm := time.AfterFunc(PREVIEW_TIMEOUT, msn.Cancel)
+
+	start := time.Now()
+	status := clctr.Collect(msn.New(), job)
+	end := time.Now()
+
+	tm.Stop()
+	msn.Done()
+
+	job.Wait()
+	close(artcl_ch)
+	artcls := <- done
+
+	if !status.IsSuccess {
+		return nil, nil, fmt.Errorf("%s", status.Log)
+	}
+	return artcls, checkPreviewArticles(artcls, start, end), nil
+}
+
+func checkPreviewArticles(artcls []*model.Article, start time.Time, end time.Time) []string {
+	warns := []string{}
+	if len(artcls) < 1 {
+		warns = append(warns, "no articles are collected.")
+	}
+
+	for i, artcl := range artcls {
+		name := fmt.Sprintf("#%d '%s'", i + 1, artcl.Title())
+		if strings.TrimSpace(artcl.Title()) == "" {
+			name = fmt.Sprintf("#%d '%s'", i + 1, artcl.Link())
+			warns = append(warns, fmt.Sprintf("%s: the title is empty.", name))
+		}
+		// a collector uses the time of the collection for an article without a date.
+		if artcl.Unixtime() >= start.Unix() && artcl.Unixtime() <= end.Unix() {
+			warns = append(warns, fmt.Sprintf("%s: the date is missing or cannot be parsed.", name))
+		}
+		if !isValidText(artcl.Title()) || !isValidText(artcl.Body()) {
+			warns = append(warns, fmt.Sprintf("%s: the text has a bad encoding.", name))
+		}
+	}
+	return warns
+}
+
+func isValidText(s string) bool {
+	return utf8.ValidString(s) && !strings.ContainsRune(s, utf8.RuneError)
+}
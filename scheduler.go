@@ -0,0 +1,255 @@
+package gwyneth
+
+import (
+	"sync"
+	"time"
+	"hash/fnv"
+	"math/rand"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/config"
+)
+
+const (
+	SCHEDULE_IDLE_STEP   = 3              // the interval is doubled every this count of idle collections.
+	SCHEDULE_MAX_BACKOFF = 8
+	SCHEDULE_MAX_HINT    = 24 * time.Hour // the longest period to follow the hint of a source.
+	SCHEDULE_MAX_SKIP    = 7 * 24
+
+	RETRY_MIN_DELAY      = time.Minute    // the first delay of the retry after a transient failure.
+)
+
+type schedule struct {
+	src      *model.Source
+	interval time.Duration
+	next     time.Time
+	running  bool
+	again    bool
+}
+
+type scheduler struct {
+	default_interval int
+
+	idx  map[string]*schedule
+	push map[string]time.Time
+	mtx  *sync.Mutex
+}
+
+func newScheduler(default_interval int) *scheduler {
+	return &scheduler{
+		default_interval: default_interval,
+		idx: make(map[string]*schedule),
+		push: make(map[string]time.Time),
+		mtx: new(sync.Mutex),
+	}
+}
+
+func (self *scheduler) interval(src *model.Source) time.Duration {
+	if src.Interval() > 0 {
+		return time.Duration(src.Interval()) * time.Second
+	}
+	return time.Duration(self.default_interval) * time.Second
+}
+
+func (self *scheduler) Sync(src_s []*model.Source, now time.Time, restore func(*model.Source) time.Time) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	idx := make(map[string]*schedule)
+	for _, src := range src_s {
+		interval := self.interval(src)
+
+		sch, ok := self.idx[src.Id().String()]
+		if !ok {
+			sch = &schedule{
+				interval: interval,
+				next: now.Add(offset(src.Id(), interval)),
+			}
+			if next := restore(src); !next.IsZero() {
+				sch.next = next
+			}
+		}
+		sch.src = src
+		if sch.interval != interval {
+			if limit := now.Add(interval); sch.next.After(limit) {
+				sch.next = limit
+			}
+			sch.interval = interval
+		}
+
+		idx[src.Id().String()] = sch
+	}
+	self.idx = idx
+}
+
+func (self *scheduler) Due(now time.Time) []*model.Source {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	ret := []*model.Source{}
+	for _, sch := range self.idx {
+		if sch.running {
+			continue
+		}
+		if sch.next.After(now) {
+			continue
+		}
+
+		sch.running = true
+		ret = append(ret, sch.src)
+	}
+	return ret
+}
+
+func (self *scheduler) Done(id *model.Id, now time.Time, st *model.SourceState) time.Time {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	sch, ok := self.idx[id.String()]
+	if !ok {
+		return time.Time{}
+	}
+	sch.running = false
+	sch.next = self.nextFetch(sch.src, now, st)
+	if sch.again {
+		sch.again = false
+		sch.next = now
+	}
+	return sch.next
+}
+
+// Trigger makes the source due now. A running source is collected again after it.
+func (self *scheduler) Trigger(id *model.Id, now time.Time) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	sch, ok := self.idx[id.String()]
+	if !ok {
+		return false
+	}
+	if sch.running {
+		sch.again = true
+		return true
+	}
+	sch.next = now
+	return true
+}
+
+// SetPush tells that the articles of the source are pushed until the time.
+// The source is still collected, but rarely, and normally again after the time.
+func (self *scheduler) SetPush(id *model.Id, until time.Time) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	if until.IsZero() {
+		delete(self.push, id.String())
+		return
+	}
+	self.push[id.String()] = until
+}
+
+func (self *scheduler) Next(id *model.Id) (time.Time, bool) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	sch, ok := self.idx[id.String()]
+	if !ok {
+		return time.Time{}, false
+	}
+	return sch.next, true
+}
+
+func (self *scheduler) Release(id *model.Id) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	sch, ok := self.idx[id.String()]
+	if !ok {
+		return
+	}
+	sch.running = false
+}
+
+func (self *scheduler) nextFetch(src *model.Source, now time.Time, st *model.SourceState) time.Time {
+	interval := self.interval(src)
+	if st == nil {
+		return now.Add(interval)
+	}
+
+	hint := time.Duration(st.TTL) * time.Second
+	if hint > SCHEDULE_MAX_HINT {
+		hint = SCHEDULE_MAX_HINT
+	}
+	if hint > interval {
+		interval = hint
+	}
+
+	if st.Idle >= SCHEDULE_IDLE_STEP {
+		backoff := 1 << uint(st.Idle / SCHEDULE_IDLE_STEP)
+		if backoff > SCHEDULE_MAX_BACKOFF {
+			backoff = SCHEDULE_MAX_BACKOFF
+		}
+		interval *= time.Duration(backoff)
+	}
+	if st.Idle == 0 && st.LastUpdate > 0 && now.Sub(time.Unix(st.LastUpdate, 0)) < interval {
+		interval /= 2
+
+		min := time.Duration(config.MIN_COLLECTOR_INTERVAL) * time.Second
+		if interval < min {
+			interval = min
+		}
+		if interval < hint {
+			interval = hint
+		}
+	}
+
+	next := now.Add(interval)
+	if st.Failures > 0 {
+		next = now.Add(retryDelay(interval, st.Failures, st.Permanent))
+	}
+	if until, ok := self.push[src.Id().String()]; ok && until.After(now) && st.Failures < 1 {
+		next = now.Add(interval * SCHEDULE_MAX_BACKOFF)
+		if next.After(until) {
+			next = until
+		}
+	}
+	if retry_after := time.Unix(st.RetryAfter, 0); st.RetryAfter > 0 && retry_after.After(next) {
+		next = retry_after
+	}
+	for i := 0; i < SCHEDULE_MAX_SKIP && st.IsSkip(next); i++ {
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+	return next
+}
+
+// retryDelay doubles the delay every failure with a jitter, up to SCHEDULE_MAX_BACKOFF times of the interval.
+// A transient failure is retried soon at first, and a permanent one is retried after the interval.
+func retryDelay(interval time.Duration, failures int, permanent bool) time.Duration {
+	delay := RETRY_MIN_DELAY
+	if permanent || delay > interval {
+		delay = interval
+	}
+
+	max := interval * SCHEDULE_MAX_BACKOFF
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half) + 1))
+}
+
+func offset(id *model.Id, interval time.Duration) time.Duration {
+	if interval < time.Second {
+		return 0
+	}
+
+	h := fnv.New32a()
+	h.Write(id.Value())
+	return time.Duration(h.Sum32() % uint32(interval / time.Second)) * time.Second
+}
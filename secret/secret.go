@@ -0,0 +1,42 @@
+package secret
+
+import (
+	"os"
+	"fmt"
+	"path/filepath"
+)
+
+import (
+	"gopkg.in/yaml.v2"
+)
+
+// Store is the secrets which are referenced by name, e.g. the credentials of the sources.
+// The file is a yaml map of the names to the values, and it is read on every lookup so it can be rotated without restart.
+type Store struct {
+	path string
+}
+
+func New(path string) *Store {
+	return &Store{path: path}
+}
+
+func (self *Store) Get(name string) (string, error) {
+	if self.path == "" {
+		return "", fmt.Errorf("the secrets store is not configured.")
+	}
+
+	b, err := os.ReadFile(filepath.Clean(self.path))
+	if err != nil {
+		return "", fmt.Errorf("cannot read the secrets store: %s", err)
+	}
+	secrets := map[string]string{}
+	if err := yaml.Unmarshal(b, &secrets); err != nil {
+		return "", fmt.Errorf("cannot parse the secrets store: %s", err)
+	}
+
+	val, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("cannot find the secret: '%s'", name)
+	}
+	return val, nil
+}
@@ -0,0 +1,257 @@
+package smtp
+
+import (
+	"io"
+	"fmt"
+	"net"
+	"time"
+	"bytes"
+	"strings"
+	"net/textproto"
+)
+
+import (
+	"github.com/l4go/task"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth"
+	"github.com/hinoshiba/gwyneth/slog"
+	"github.com/hinoshiba/gwyneth/config"
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/collector/mail"
+)
+
+const (
+	SESSION_TIMEOUT = 5 * time.Minute
+	MAX_RECIPIENTS  = 100
+
+	SOURCE_TYPE = "noop"
+)
+
+// Server accepts a mail to '<source id>@<domain>' and records it as an article of the source.
+type Server struct {
+	cfg *config.Smtp
+	g   *gwyneth.Gwyneth
+	ln  net.Listener
+
+	msn *task.Mission
+}
+
+func New(msn *task.Mission, cfg *config.Smtp, g *gwyneth.Gwyneth) (*Server, error) {
+	self := &Server{
+		cfg: cfg,
+		g: g,
+		msn: msn,
+	}
+
+	if err := self.run(); err != nil {
+		self.Close()
+		return nil, err
+	}
+	return self, nil
+}
+
+func (self *Server) Close() error {
+	defer self.msn.Done()
+
+	self.msn.Cancel()
+	if self.ln != nil {
+		return self.ln.Close()
+	}
+	return nil
+}
+
+func (self *Server) run() error {
+	lc := net.ListenConfig{}
+	ln, err := lc.Listen(self.msn.AsContext(), "tcp", self.cfg.GetAddr())
+	if err != nil {
+		return err
+	}
+	self.ln = ln
+
+	go func(msn *task.Mission) {
+		defer msn.Done()
+
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if task.IsCanceled(msn) {
+					return
+				}
+				slog.Warn("smtp: cannot accept: %s", err)
+				continue
+			}
+
+			go self.serve(msn.New(), conn)
+		}
+	}(self.msn.New())
+	return nil
+}
+
+type session struct {
+	from string
+	rcpt []*model.Source
+}
+
+func (self *Server) serve(msn *task.Mission, conn net.Conn) {
+	defer msn.Done()
+	defer conn.Close()
+
+	go func() {
+		select {
+		case <- msn.RecvDone():
+		case <- msn.RecvCancel():
+			conn.Close()
+		}
+	}()
+
+	tc := textproto.NewConn(conn)
+	reply := func(code int, msg string) error {
+		conn.SetDeadline(time.Now().Add(SESSION_TIMEOUT))
+		return tc.PrintfLine("%d %s", code, msg)
+	}
+
+	hostname := self.cfg.Domain
+	if hostname == "" {
+		hostname = "gwyneth"
+	}
+	if err := reply(220, hostname + " ESMTP gwyneth"); err != nil {
+		return
+	}
+
+	ss := &session{}
+	for {
+		conn.SetDeadline(time.Now().Add(SESSION_TIMEOUT))
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		cmd = strings.ToUpper(cmd)
+		arg = strings.TrimSpace(arg)
+
+		switch cmd {
+		case "HELO":
+			reply(250, hostname)
+		case "EHLO":
+			tc.PrintfLine("250-%s", hostname)
+			tc.PrintfLine("250-SIZE %d", self.cfg.MaxSize)
+			tc.PrintfLine("250-8BITMIME")
+			reply(250, "SMTPUTF8")
+		case "MAIL":
+			addr, ok := parsePath(arg, "FROM:")
+			if !ok {
+				reply(501, "syntax error in MAIL command")
+				continue
+			}
+			ss = &session{from: addr}
+			reply(250, "ok")
+		case "RCPT":
+			addr, ok := parsePath(arg, "TO:")
+			if !ok {
+				reply(501, "syntax error in RCPT command")
+				continue
+			}
+			if len(ss.rcpt) >= MAX_RECIPIENTS {
+				reply(452, "too many recipients")
+				continue
+			}
+			src, err := self.lookupSource(addr)
+			if err != nil {
+				reply(550, err.Error())
+				continue
+			}
+			ss.rcpt = append(ss.rcpt, src)
+			reply(250, "ok")
+		case "DATA":
+			if len(ss.rcpt) < 1 {
+				reply(503, "need RCPT command")
+				continue
+			}
+			reply(354, "end data with <CR><LF>.<CR><LF>")
+
+			code, msg := self.receive(ss, tc.DotReader())
+			reply(code, msg)
+			ss = &session{}
+		case "RSET":
+			ss = &session{}
+			reply(250, "ok")
+		case "NOOP":
+			reply(250, "ok")
+		case "VRFY":
+			reply(252, "cannot verify the user")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "command not implemented")
+		}
+	}
+}
+
+func (self *Server) receive(ss *session, r io.Reader) (int, string) {
+	b, err := io.ReadAll(io.LimitReader(r, int64(self.cfg.MaxSize) + 1))
+	if err != nil {
+		return 451, "cannot read the message"
+	}
+	if len(b) > self.cfg.MaxSize {
+		// drains the rest of the message.
+		io.Copy(io.Discard, r)
+		return 552, "message size exceeds the limit"
+	}
+
+	m, err := mail.ParseMessage(bytes.NewReader(b))
+	if err != nil {
+		return 554, fmt.Sprintf("cannot parse the message: %s", err)
+	}
+
+	for _, src := range ss.rcpt {
+		if err := self.g.PushArticle(m.Article(src)); err != nil {
+			// the sending server retries it later.
+			return 451, fmt.Sprintf("cannot record the message: %s", err)
+		}
+		slog.Debug("smtp: received '%s' from '%s' to '%s'", m.Subject, ss.from, src.Title())
+	}
+	return 250, "ok"
+}
+
+func (self *Server) lookupSource(addr string) (*model.Source, error) {
+	local, domain, ok := strings.Cut(addr, "@")
+	if !ok {
+		return nil, fmt.Errorf("no such user: %s", addr)
+	}
+	if self.cfg.Domain != "" && !strings.EqualFold(domain, self.cfg.Domain) {
+		return nil, fmt.Errorf("relay access denied: %s", addr)
+	}
+
+	id, err := model.ParseStringId(local)
+	if err != nil {
+		return nil, fmt.Errorf("no such user: %s", addr)
+	}
+	src, err := self.g.GetSource(id)
+	if err != nil {
+		return nil, fmt.Errorf("no such user: %s", addr)
+	}
+	if src.Type().Name() != SOURCE_TYPE {
+		return nil, fmt.Errorf("the source does not accept a mail: %s", addr)
+	}
+	if src.IsPause() {
+		return nil, fmt.Errorf("the source is paused: %s", addr)
+	}
+	return src, nil
+}
+
+func parsePath(arg string, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	path := strings.TrimSpace(arg[len(prefix):])
+	if idx := strings.Index(path, ">"); idx >= 0 {
+		path = path[:idx + 1]
+	}
+	if !strings.HasPrefix(path, "<") || !strings.HasSuffix(path, ">") {
+		return "", false
+	}
+	return path[1:len(path) - 1], true
+}
@@ -27,7 +27,8 @@ type Session interface {
 	GetSourceTypes() ([]*model.SourceType, error)
 	DeleteSourceType(*model.Id) error
 
-	AddSource(string, *model.Id, string) (*model.Source, error)
+	AddSource(string, *model.Id, string, int, string) (*model.Source, error)
+	UpdateSource(*model.Id, string, string, int, string) (*model.Source, error)
 	GetSource(*model.Id) (*model.Source, error)
 	GetSources() ([]*model.Source, error)
 	FindSource(string) ([]*model.Source, error)
@@ -35,8 +36,25 @@ type Session interface {
 	PauseSource(*model.Id) error
 	ResumeSource(*model.Id) error
 
-	AddArticle(string, string, string, int64, string, *model.Id) (*model.Article, error)
-	LookupArticles(string, string, []*model.Id, int64, int64, int64) ([]*model.Article, error)
+	GetSourceState(*model.Id) (*model.SourceState, error)
+	UpdateSourceState(*model.Id, *model.SourceState) error
+	GetSourceOption(*model.Id) (*model.SourceOption, error)
+	UpdateSourceOption(*model.Id, *model.SourceOption) error
+
+	AddFetchLog(*model.Id, *model.FetchLog) error
+	GetFetchLogs(*model.Id, int64, int64) ([]*model.FetchLog, error)
+	GetLatestFetchLogs(int64) (map[string][]*model.FetchLog, error)
+	DeleteFetchLogs(int64) (int64, error)
+
+	GetSubscriptions() ([]*model.Subscription, error)
+	GetSubscription(*model.Id) (*model.Subscription, error)
+	UpdateSubscription(*model.Subscription) error
+	DeleteSubscription(*model.Id) error
+
+	AddArticle(string, string, string, int64, string, *model.ArticleMeta, *model.Id) (*model.Article, error)
+	GetArticleRevisions(*model.Id) ([]*model.ArticleRevision, error)
+	UpdateArticleFullText(*model.Id, string) error
+	LookupArticles(string, string, string, string, []*model.Id, int64, int64, int64) ([]*model.Article, error)
 	RemoveArticle(*model.Id) error
 
 	GetFeed(*model.Id, int64) ([]*model.Article, error)
@@ -48,8 +66,9 @@ type Session interface {
 	GetActions() ([]*filter.Action, error)
 	DeleteAction(id *model.Id) error
 
-	AddFilter(title string, regex_title bool, body string, regex_body bool, action_id *model.Id) (*filter.Filter, error)
+	AddFilter(title string, regex_title bool, body string, regex_body bool, on_update bool, action_id *model.Id) (*filter.Filter, error)
 	UpdateFilterAction(id *model.Id, action_id *model.Id) (*filter.Filter, error)
+	UpdateFilterOnUpdate(id *model.Id, on_update bool) (*filter.Filter, error)
 	GetFilter(id *model.Id) (*filter.Filter, error)
 	GetFilters() ([]*filter.Filter, error)
 	DeleteFilter(id *model.Id) error
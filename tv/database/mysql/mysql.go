@@ -6,6 +6,7 @@ import (
 	"time"
 	"strings"
 	"database/sql"
+	"encoding/json"
 )
 
 import (
@@ -17,6 +18,7 @@ import (
 	"github.com/hinoshiba/gwyneth/slog"
 	"github.com/hinoshiba/gwyneth/config"
 	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/model/external"
 	"github.com/hinoshiba/gwyneth/filter"
 
 	"github.com/hinoshiba/gwyneth/tv/errors"
@@ -24,6 +26,9 @@ import (
 
 const (
 	MAX_RETRY int = 7
+
+	SELECT_SOURCE string = "SELECT id, title, type, source, pause, interval_sec, group_name FROM source"
+	FILTER_COLUMNS string = "id, val_title, is_regex_title, val_body, is_regex_body, on_update, action_id"
 )
 
 type Session struct {
@@ -171,9 +176,163 @@ func (self *Session) init() error {
 			return fmt.Errorf("%s: '%s'", err, query)
 		}
 	}
+	return self.migrate()
+}
+
+func (self *Session) migrate() error {
+	order, d := make_column_dict()
+	for _, name := range order {
+		cols, ok := d[name]
+		if !ok {
+			continue
+		}
+
+		exists, err := self.getColumnNames(name)
+		if err != nil {
+			return err
+		}
+		for _, col := range cols {
+			if _, ok := exists[col.name]; ok {
+				continue
+			}
+
+			query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", name, col.name, col.def)
+			if _, err := self.db.ExecContext(self.msn.AsContext(), query); err != nil {
+				return fmt.Errorf("%s: '%s'", err, query)
+			}
+			slog.Info("database migrated: %s.%s", name, col.name)
+		}
+	}
+
+	for _, idx := range make_index_list() {
+		exists, err := self.getIndexNames(idx.table)
+		if err != nil {
+			return err
+		}
+		if _, ok := exists[idx.name]; ok {
+			continue
+		}
+
+		query := fmt.Sprintf("CREATE INDEX %s ON %s (%s)", idx.name, idx.table, idx.columns)
+		if _, err := self.db.ExecContext(self.msn.AsContext(), query); err != nil {
+			return fmt.Errorf("%s: '%s'", err, query)
+		}
+		slog.Info("database migrated: %s.%s", idx.table, idx.name)
+	}
+
+	return self.fillDedupKeys()
+}
+
+func (self *Session) getIndexNames(table string) (map[string]struct{}, error) {
+	rows, err := self.db.QueryContext(self.msn.AsContext(),
+		"SELECT DISTINCT index_name FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ?", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]struct{})
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[name] = struct{}{}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// fillDedupKeys sets the dedup keys of the articles which are recorded by the old versions.
+func (self *Session) fillDedupKeys() error {
+	cnt := 0
+	for {
+		n, err := self.updateDedupKeys("a.dedup_key = ''")
+		if err != nil {
+			return err
+		}
+		if n < 1 {
+			break
+		}
+		cnt += n
+	}
+	if cnt > 0 {
+		slog.Info("database migrated: the dedup keys of %d articles", cnt)
+	}
 	return nil
 }
 
+const DEDUP_KEY_BATCH_SIZE = 1000
+
+// updateDedupKeys recomputes the dedup keys of the articles which match the condition, with the strategy of their source.
+// It returns the number of the updated articles, and it updates DEDUP_KEY_BATCH_SIZE articles at most.
+func (self *Session) updateDedupKeys(cond string, args ...any) (int, error) {
+	q := "SELECT a.id, a.title, a.body, a.link, a.guid, COALESCE(o.dedup, '') FROM article a " +
+		"LEFT JOIN source_option o ON a.src_id = o.src_id WHERE " + cond + " LIMIT ?"
+	rows, err := self.db.QueryContext(self.msn.AsContext(), q, append(args, DEDUP_KEY_BATCH_SIZE)...)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := [][]byte{}
+	keys := []string{}
+	for rows.Next() {
+		var id []byte
+		var title, body, link, guid, dedup string
+		if err := rows.Scan(&id, &title, &body, &link, &guid, &dedup); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		keys = append(keys, model.DedupKeys(dedup, title, body, link, guid)[0])
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i, id := range ids {
+		_, err := self.db.ExecContext(self.msn.AsContext(), "UPDATE article SET dedup_key = ? WHERE id = ?", keys[i], id)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+func (self *Session) getColumnNames(table string) (map[string]struct{}, error) {
+	rows, err := self.db.QueryContext(self.msn.AsContext(), fmt.Sprintf("SHOW COLUMNS FROM %s", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	col_types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]struct{})
+	for rows.Next() {
+		vals := make([]any, len(col_types))
+		for i, _ := range vals {
+			vals[i] = new(sql.RawBytes)
+		}
+		if err := rows.Scan(vals...); err != nil {
+			return nil, err
+		}
+		names[string(*vals[0].(*sql.RawBytes))] = struct{}{}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
 func (self *Session) AddSourceType(name string, command string, is_user_creation bool) (*model.SourceType, error) {
 	self.mtx.Lock()
 	defer self.mtx.Unlock()
@@ -270,19 +429,45 @@ func (self *Session) DeleteSourceType(id *model.Id) error {
 	return err
 }
 
-func (self *Session) AddSource(title string, src_type_id *model.Id, source string) (*model.Source, error) {
+func (self *Session) AddSource(title string, src_type_id *model.Id, source string, interval int, group string) (*model.Source, error) {
 	self.mtx.Lock()
 	defer self.mtx.Unlock()
 
 	id := model.NewId(nil)
 
 	_, err := self.db.ExecContext(self.msn.AsContext(),
-		"INSERT INTO source (id, title, type, source) VALUES (?, ?, ?, ?)",
-								id.Value(), title, src_type_id.Value(), source)
+		"INSERT INTO source (id, title, type, source, interval_sec, group_name) VALUES (?, ?, ?, ?, ?, ?)",
+								id.Value(), title, src_type_id.Value(), source, interval, group)
+	if err != nil {
+		return nil, err
+	}
+
+	return self.getSource(id)
+}
+
+func (self *Session) UpdateSource(id *model.Id, title string, source string, interval int, group string) (*model.Source, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	src, err := self.getSource(id)
 	if err != nil {
 		return nil, err
 	}
 
+	_, err = self.db.ExecContext(self.msn.AsContext(),
+		"UPDATE source SET title = ?, source = ?, interval_sec = ?, group_name = ? WHERE id = ?",
+								title, source, interval, group, id.Value())
+	if err != nil {
+		return nil, err
+	}
+	if src.Value() != source {
+		_, err := self.db.ExecContext(self.msn.AsContext(),
+			"DELETE FROM source_state WHERE src_id = ?", id.Value())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return self.getSource(id)
 }
 
@@ -290,7 +475,36 @@ func (self *Session) GetSources() ([]*model.Source, error) {
 	self.mtx.RLock()
 	defer self.mtx.RUnlock()
 
-	rows, err := self.db.Query("SELECT id, title, type, source, pause FROM source WHERE disable <> 1 ORDER BY title ASC")
+	return self.query4source(SELECT_SOURCE + " WHERE disable <> 1 ORDER BY title ASC")
+}
+
+func (self *Session) FindSource(kw string) ([]*model.Source, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	return self.query4source(SELECT_SOURCE + " WHERE title = ? AND disable <> 1 ORDER BY title ASC", kw)
+}
+
+func (self *Session) GetSource(id *model.Id) (*model.Source, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	return self.getSource(id)
+}
+
+func (self *Session) getSource(id *model.Id) (*model.Source, error) {
+	srcs, err := self.query4source(SELECT_SOURCE + " WHERE id = ? ORDER BY id ASC LIMIT 1", id.Value())
+	if err != nil {
+		return nil, err
+	}
+	if len(srcs) < 1 {
+		return nil, fmt.Errorf("cannot find the source.")
+	}
+	return srcs[0], nil
+}
+
+func (self *Session) query4source(q string, args ...any) ([]*model.Source, error) {
+	rows, err := self.db.Query(q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -304,8 +518,10 @@ func (self *Session) GetSources() ([]*model.Source, error) {
 		var source_type_id_base []byte
 		var source string
 		var pause bool
+		var interval int
+		var group string
 
-		err := rows.Scan(&id_base, &title, &source_type_id_base, &source, &pause)
+		err := rows.Scan(&id_base, &title, &source_type_id_base, &source, &pause, &interval, &group)
 		if err != nil {
 			return nil, err
 		}
@@ -323,7 +539,7 @@ func (self *Session) GetSources() ([]*model.Source, error) {
 			st_cache[source_type_id.String()] = st
 		}
 
-		srcs = append(srcs, model.NewSource(id, title, st, source, pause))
+		srcs = append(srcs, model.NewSource(id, title, st, source, pause, interval, group))
 	}
 
 	if err := rows.Err(); err != nil {
@@ -332,157 +548,428 @@ func (self *Session) GetSources() ([]*model.Source, error) {
 	return srcs, nil
 }
 
-func (self *Session) FindSource(kw string) ([]*model.Source, error) {
+func (self *Session) RemoveSource(id *model.Id) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	if _, err := self.getSource(id); err != nil {
+		return err
+	}
+
+	_, err := self.db.ExecContext(self.msn.AsContext(),
+		"UPDATE source SET disable = 1, pause = 1 WHERE id = ?", id.Value())
+	return err
+}
+
+func (self *Session) PauseSource(id *model.Id) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	if _, err := self.getSource(id); err != nil {
+		return err
+	}
+
+	_, err := self.db.ExecContext(self.msn.AsContext(),
+		"UPDATE source SET pause = 1 WHERE id = ?", id.Value())
+	return err
+}
+
+func (self *Session) ResumeSource(id *model.Id) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	if _, err := self.getSource(id); err != nil {
+		return err
+	}
+
+	_, err := self.db.ExecContext(self.msn.AsContext(),
+		"UPDATE source SET pause = 0 WHERE id = ?", id.Value())
+	return err
+}
+
+func (self *Session) GetSourceState(src_id *model.Id) (*model.SourceState, error) {
 	self.mtx.RLock()
 	defer self.mtx.RUnlock()
 
-	rows, err := self.db.Query("SELECT id, title, type, source, pause FROM source WHERE title = ? AND disable <> 1 ORDER BY title ASC", kw)
+	rows, err := self.db.Query("SELECT etag, last_modified, ttl_sec, skip_hours, skip_days, retry_after, last_update, idle, next_fetch, hub, topic, cursor_pos, failures, permanent, quarantine, quarantined FROM source_state WHERE src_id = ? LIMIT 1", src_id.Value())
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	srcs := []*model.Source{}
-	st_cache := make(map[string]*model.SourceType)
+	st := &model.SourceState{}
 	for rows.Next() {
-		var id_base []byte
-		var title string
-		var source_type_id_base []byte
-		var source string
-		var pause bool
-
-		err := rows.Scan(&id_base, &title, &source_type_id_base, &source, &pause)
-		if err != nil {
+		if err := rows.Scan(&st.ETag, &st.LastModified, &st.TTL, &st.SkipHours, &st.SkipDays,
+				&st.RetryAfter, &st.LastUpdate, &st.Idle, &st.NextFetch, &st.Hub, &st.Topic, &st.Cursor,
+				&st.Failures, &st.Permanent, &st.Quarantine, &st.Quarantined); err != nil {
 			return nil, err
 		}
-		id := model.NewId(id_base)
-		source_type_id := model.NewId(source_type_id_base)
-
-		st, ok := st_cache[source_type_id.String()]
-		if !ok {
-			var err error
-			st, err = self.getSourceType(source_type_id)
-			if err != nil {
-				return nil, err
-			}
-
-			st_cache[source_type_id.String()] = st
-		}
-
-		srcs = append(srcs, model.NewSource(id, title, st, source, pause))
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return srcs, nil
+	return st, nil
 }
 
-func (self *Session) GetSource(id *model.Id) (*model.Source, error) {
+func (self *Session) UpdateSourceState(src_id *model.Id, st *model.SourceState) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	_, err := self.db.ExecContext(self.msn.AsContext(),
+		"INSERT INTO source_state (src_id, etag, last_modified, ttl_sec, skip_hours, skip_days, retry_after, last_update, idle, next_fetch, hub, topic, cursor_pos, " +
+		"failures, permanent, quarantine, quarantined) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE etag = VALUES(etag), last_modified = VALUES(last_modified), ttl_sec = VALUES(ttl_sec), skip_hours = VALUES(skip_hours), skip_days = VALUES(skip_days), " +
+		"retry_after = VALUES(retry_after), last_update = VALUES(last_update), idle = VALUES(idle), next_fetch = VALUES(next_fetch), hub = VALUES(hub), topic = VALUES(topic), cursor_pos = VALUES(cursor_pos), " +
+		"failures = VALUES(failures), permanent = VALUES(permanent), quarantine = VALUES(quarantine), quarantined = VALUES(quarantined)",
+			src_id.Value(), st.ETag, st.LastModified, st.TTL, st.SkipHours, st.SkipDays,
+			st.RetryAfter, st.LastUpdate, st.Idle, st.NextFetch, st.Hub, st.Topic, st.Cursor,
+			st.Failures, st.Permanent, st.Quarantine, st.Quarantined)
+	return err
+}
+
+func (self *Session) GetSourceOption(src_id *model.Id) (*model.SourceOption, error) {
 	self.mtx.RLock()
 	defer self.mtx.RUnlock()
 
-	return self.getSource(id)
+	return self.getSourceOption(src_id)
 }
 
-func (self *Session) getSource(id *model.Id) (*model.Source, error) {
-	rows, err := self.db.Query("SELECT id, title, type, source, pause FROM source WHERE id = ? ORDER BY id ASC LIMIT 1", id.Value())
+func (self *Session) getSourceOption(src_id *model.Id) (*model.SourceOption, error) {
+	rows, err := self.db.Query("SELECT full_text, dedup, http, hook FROM source_option WHERE src_id = ? LIMIT 1", src_id.Value())
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var id_base []byte
-	var title string
-	var source_type_id_base []byte
-	var source string
-	var pause bool
-
+	opt := &model.SourceOption{}
 	for rows.Next() {
-		if err = rows.Scan(&id_base, &title, &source_type_id_base, &source, &pause); err != nil {
+		var http_opt string
+		var hook_opt string
+		if err := rows.Scan(&opt.FullText, &opt.Dedup, &http_opt, &hook_opt); err != nil {
 			return nil, err
 		}
-		id = model.NewId(id_base)
-		source_type_id := model.NewId(source_type_id_base)
-
-		st, err := self.getSourceType(source_type_id)
-		if err != nil {
-			return nil, err
+		if http_opt != "" {
+			var ex_http_opt external.HttpOption
+			if err := json.Unmarshal([]byte(http_opt), &ex_http_opt); err != nil {
+				return nil, err
+			}
+			opt.Http = model.ImportExternalHttpOption(&ex_http_opt)
 		}
-
-		if err = rows.Err(); err != nil {
-			return nil, err
+		if hook_opt != "" {
+			var ex_hook_opt external.HookOption
+			if err := json.Unmarshal([]byte(hook_opt), &ex_hook_opt); err != nil {
+				return nil, err
+			}
+			opt.Hook = model.ImportExternalHookOption(&ex_hook_opt)
 		}
-		return model.NewSource(id, title, st, source, pause), nil
 	}
-	return nil, fmt.Errorf("cannot find the source.")
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if opt.Dedup == "" {
+		opt.Dedup = model.DEDUP_AUTO
+	}
+	return opt, nil
 }
 
-func (self *Session) RemoveSource(id *model.Id) error {
+func (self *Session) UpdateSourceOption(src_id *model.Id, opt *model.SourceOption) error {
 	self.mtx.Lock()
 	defer self.mtx.Unlock()
 
-	if _, err := self.getSource(id); err != nil {
+	if _, err := self.getSource(src_id); err != nil {
+		return err
+	}
+
+	dedup, err := model.ParseDedup(opt.Dedup)
+	if err != nil {
+		return err
+	}
+	old, err := self.getSourceOption(src_id)
+	if err != nil {
 		return err
 	}
 
+	http_opt := ""
+	if opt.Http != nil {
+		b, err := json.Marshal(opt.Http.ConvertExternal())
+		if err != nil {
+			return err
+		}
+		http_opt = string(b)
+	}
+	hook_opt := ""
+	if opt.Hook != nil {
+		b, err := json.Marshal(opt.Hook.ConvertExternal())
+		if err != nil {
+			return err
+		}
+		hook_opt = string(b)
+	}
+
+	_, err = self.db.ExecContext(self.msn.AsContext(),
+		"INSERT INTO source_option (src_id, full_text, dedup, http, hook) VALUES (?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE full_text = VALUES(full_text), dedup = VALUES(dedup), http = VALUES(http), hook = VALUES(hook)",
+			src_id.Value(), opt.FullText, dedup, http_opt, hook_opt)
+	if err != nil {
+		return err
+	}
+
+	if old.Dedup == dedup {
+		return nil
+	}
+	// the keys are recomputed with the new strategy, or the recorded articles are collected again.
+	_, err = self.db.ExecContext(self.msn.AsContext(),
+		"UPDATE article SET dedup_key = '' WHERE src_id = ?", src_id.Value())
+	if err != nil {
+		return err
+	}
+	for {
+		n, err := self.updateDedupKeys("a.src_id = ? AND a.dedup_key = ''", src_id.Value())
+		if err != nil {
+			return err
+		}
+		if n < 1 {
+			return nil
+		}
+	}
+}
+
+func (self *Session) AddFetchLog(src_id *model.Id, l *model.FetchLog) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
 	_, err := self.db.ExecContext(self.msn.AsContext(),
-		"UPDATE source SET disable = 1, pause = 1 WHERE id = ?", id.Value())
+		"INSERT INTO source_fetch_log (id, src_id, timestamp, duration_ms, http_status, bytes, items, new_articles, success, permanent, log) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			model.NewId(nil).Value(), src_id.Value(), time.Unix(l.Unixtime, 0), l.Duration.Milliseconds(),
+			l.HttpStatus, l.Bytes, l.Items, l.NewArticles, l.IsSuccess, l.IsPermanent, l.Log)
 	return err
 }
 
-func (self *Session) PauseSource(id *model.Id) error {
+// GetFetchLogs returns the latest logs of the source since the unixtime in chronological order.
+func (self *Session) GetFetchLogs(src_id *model.Id, since int64, limit int64) ([]*model.FetchLog, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	q := "SELECT timestamp, duration_ms, http_status, bytes, items, new_articles, success, permanent, log FROM source_fetch_log WHERE src_id = ? AND timestamp >= ? ORDER BY timestamp DESC"
+	args := []any{src_id.Value(), time.Unix(since, 0)}
+	if limit > 0 {
+		q += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := self.db.QueryContext(self.msn.AsContext(), q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []*model.FetchLog{}
+	for rows.Next() {
+		l := &model.FetchLog{}
+		var timestamp time.Time
+		var duration int64
+		if err := rows.Scan(&timestamp, &duration, &l.HttpStatus, &l.Bytes, &l.Items, &l.NewArticles,
+				&l.IsSuccess, &l.IsPermanent, &l.Log); err != nil {
+			return nil, err
+		}
+		l.Unixtime = timestamp.Unix()
+		l.Duration = time.Duration(duration) * time.Millisecond
+
+		logs = append(logs, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(logs) - 1; i < j; i, j = i + 1, j - 1 {
+		logs[i], logs[j] = logs[j], logs[i]
+	}
+	return logs, nil
+}
+
+// GetLatestFetchLogs returns the latest logs of all the sources up to limit for each source, in chronological order.
+// The key of the map is the id of the source.
+func (self *Session) GetLatestFetchLogs(limit int64) (map[string][]*model.FetchLog, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	// each source reads only its latest logs by idx_source_fetch_log_timestamp, instead of scanning all the logs.
+	q := "SELECT source.id, l.timestamp, l.duration_ms, l.http_status, l.bytes, l.items, l.new_articles, l.success, l.permanent, l.log " +
+		"FROM source JOIN LATERAL (" +
+		"SELECT timestamp, duration_ms, http_status, bytes, items, new_articles, success, permanent, log FROM source_fetch_log " +
+		"WHERE src_id = source.id ORDER BY timestamp DESC LIMIT ?" +
+		") AS l ORDER BY source.id, l.timestamp ASC"
+	rows, err := self.db.QueryContext(self.msn.AsContext(), q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := map[string][]*model.FetchLog{}
+	for rows.Next() {
+		l := &model.FetchLog{}
+		var src_id []byte
+		var timestamp time.Time
+		var duration int64
+		if err := rows.Scan(&src_id, &timestamp, &duration, &l.HttpStatus, &l.Bytes, &l.Items, &l.NewArticles,
+				&l.IsSuccess, &l.IsPermanent, &l.Log); err != nil {
+			return nil, err
+		}
+		l.Unixtime = timestamp.Unix()
+		l.Duration = time.Duration(duration) * time.Millisecond
+
+		key := model.NewId(src_id).String()
+		logs[key] = append(logs[key], l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// DeleteFetchLogs removes the logs which are older than the unixtime, and returns the count of them.
+func (self *Session) DeleteFetchLogs(before int64) (int64, error) {
 	self.mtx.Lock()
 	defer self.mtx.Unlock()
 
-	if _, err := self.getSource(id); err != nil {
-		return err
+	ret, err := self.db.ExecContext(self.msn.AsContext(),
+		"DELETE FROM source_fetch_log WHERE timestamp < ?", time.Unix(before, 0))
+	if err != nil {
+		return 0, err
+	}
+	return ret.RowsAffected()
+}
+
+func (self *Session) GetSubscriptions() ([]*model.Subscription, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	return self.query4subscription("SELECT src_id, hub, topic, secret, requested, lease_end, pending FROM websub_subscription")
+}
+
+func (self *Session) GetSubscription(src_id *model.Id) (*model.Subscription, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	subs, err := self.query4subscription("SELECT src_id, hub, topic, secret, requested, lease_end, pending FROM websub_subscription WHERE src_id = ? LIMIT 1", src_id.Value())
+	if err != nil {
+		return nil, err
 	}
+	if len(subs) < 1 {
+		return nil, fmt.Errorf("cannot find the subscription.")
+	}
+	return subs[0], nil
+}
+
+func (self *Session) UpdateSubscription(sub *model.Subscription) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
 
 	_, err := self.db.ExecContext(self.msn.AsContext(),
-		"UPDATE source SET pause = 1 WHERE id = ?", id.Value())
+		"INSERT INTO websub_subscription (src_id, hub, topic, secret, requested, lease_end, pending) VALUES (?, ?, ?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE hub = VALUES(hub), topic = VALUES(topic), secret = VALUES(secret), requested = VALUES(requested), lease_end = VALUES(lease_end), pending = VALUES(pending)",
+			sub.SrcId().Value(), sub.Hub(), sub.Topic(), sub.Secret(), sub.RequestedAt(), sub.LeaseEnd(), sub.IsPending())
 	return err
 }
 
-func (self *Session) ResumeSource(id *model.Id) error {
+func (self *Session) DeleteSubscription(src_id *model.Id) error {
 	self.mtx.Lock()
 	defer self.mtx.Unlock()
 
-	if _, err := self.getSource(id); err != nil {
-		return err
-	}
-
 	_, err := self.db.ExecContext(self.msn.AsContext(),
-		"UPDATE source SET pause = 0 WHERE id = ?", id.Value())
+		"DELETE FROM websub_subscription WHERE src_id = ?", src_id.Value())
 	return err
 }
 
+func (self *Session) query4subscription(q string, args ...any) ([]*model.Subscription, error) {
+	rows, err := self.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []*model.Subscription{}
+	for rows.Next() {
+		var src_id []byte
+		var hub string
+		var topic string
+		var secret string
+		var requested int64
+		var lease_end int64
+		var pending bool
+		if err := rows.Scan(&src_id, &hub, &topic, &secret, &requested, &lease_end, &pending); err != nil {
+			return nil, err
+		}
+
+		subs = append(subs, model.NewSubscription(model.NewId(src_id), hub, topic, secret, requested, lease_end, pending))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
 func (self *Session) getArticle(id *model.Id) (*model.Article, error) {
-	as, err := self.query4article("SELECT id, src_id, title, body, link, timestamp, raw FROM article WHERE id = ? AND disable <> 1 ORDER BY id ASC LIMIT 1", id.Value())
+	as, err := self.query4article("SELECT id, src_id, title, body, link, timestamp, raw, full_text, author, categories, guid, content, enclosures, image FROM article WHERE id = ? AND disable <> 1 ORDER BY id ASC LIMIT 1", id.Value())
 	if err != nil {
 		return nil, err
 	}
+	if len(as) < 1 {
+		return nil, fmt.Errorf("cannot find the article.")
+	}
 	return as[0], nil
 }
 
-func (self *Session) AddArticle(title string, body string, link string, unixtime int64, raw string, src_id *model.Id) (*model.Article, error){
+func (self *Session) AddArticle(title string, body string, link string, unixtime int64, raw string, meta *model.ArticleMeta, src_id *model.Id) (*model.Article, error){
 	self.mtx.Lock()
 	defer self.mtx.Unlock()
 
-	q := "SELECT id, src_id, title, body, link, timestamp, raw FROM article WHERE title = ? AND body = ? AND link = ? AND src_id = ?"
-	as, err := self.query4article(q, title, body, link, src_id.Value())
+	if meta == nil {
+		meta = &model.ArticleMeta{}
+	}
+
+	opt, err := self.getSourceOption(src_id)
+	if err != nil {
+		return nil, err
+	}
+	keys := model.DedupKeys(opt.Dedup, title, body, link, meta.Guid)
+
+	q := "SELECT id, src_id, title, body, link, timestamp, raw, full_text, author, categories, guid, content, enclosures, image FROM article WHERE src_id = ? AND disable <> 1 AND dedup_key IN (?" + strings.Repeat(", ?", len(keys) - 1) + ") LIMIT 1"
+	args := []any{src_id.Value()}
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	as, err := self.query4article(q, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := marshalList(meta.Categories)
+	if err != nil {
+		return nil, err
+	}
+	enclosures, err := marshalList(model.ConvertExternalEnclosures(meta.Enclosures))
 	if err != nil {
 		return nil, err
 	}
+
 	if !(len(as) < 1) {
-		return as[0], errors.ERR_ALREADY_EXIST_ARTICLE
+		old := as[0]
+		if old.Title() == title && old.Body() == body && old.Meta().Content == meta.Content {
+			return old, errors.ERR_ALREADY_EXIST_ARTICLE
+		}
+		return self.updateArticle(old, title, body, link, raw, meta, categories, enclosures, keys[0])
 	}
 
 	id := model.NewId(nil)
 	timestamp := time.Unix(unixtime, 0)
 	_, err = self.db.ExecContext(self.msn.AsContext(),
-		"INSERT INTO article (id, src_id, title, body, link, timestamp, raw) VALUES (?, ?, ?, ?, ?, ?, ?)",
-				id.Value(), src_id.Value(), title, body, link, timestamp, raw)
+		"INSERT INTO article (id, src_id, title, body, link, timestamp, raw, full_text, author, categories, guid, content, enclosures, image, dedup_key) VALUES (?, ?, ?, ?, ?, ?, ?, '', ?, ?, ?, ?, ?, ?, ?)",
+				id.Value(), src_id.Value(), title, body, link, timestamp, raw,
+				meta.Author, categories, meta.Guid, meta.Content, enclosures, meta.Image, keys[0])
 	if err != nil {
 		return nil, err
 	}
@@ -493,26 +980,125 @@ func (self *Session) AddArticle(title string, body string, link string, unixtime
 	return self.getArticle(id)
 }
 
-func (self *Session) LookupArticles(t_kw string, b_kw string, src_ids []*model.Id, start int64, end int64, limit int64) ([]*model.Article, error) {
+// updateArticle records the current version of the article as a revision, and replaces it with the new one.
+// The dedup key is replaced too, because the article might be matched by another key, e.g. after the guid is added.
+func (self *Session) updateArticle(old *model.Article, title string, body string, link string, raw string,
+		meta *model.ArticleMeta, categories string, enclosures string, dedup_key string) (*model.Article, error) {
+	var revision int
+	err := self.db.QueryRowContext(self.msn.AsContext(),
+		"SELECT COUNT(*) FROM article_revision WHERE article_id = ?", old.Id().Value()).Scan(&revision)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = self.db.ExecContext(self.msn.AsContext(),
+		"INSERT INTO article_revision (id, article_id, revision, title, body, content, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			model.NewId(nil).Value(), old.Id().Value(), revision, old.Title(), old.Body(), old.Meta().Content, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = self.db.ExecContext(self.msn.AsContext(),
+		"UPDATE article SET title = ?, body = ?, link = ?, raw = ?, author = ?, categories = ?, guid = ?, content = ?, enclosures = ?, image = ?, dedup_key = ? WHERE id = ?",
+			title, body, link, raw, meta.Author, categories, meta.Guid, meta.Content, enclosures, meta.Image, dedup_key, old.Id().Value())
+	if err != nil {
+		return nil, err
+	}
+
+	as, err := self.query4article("SELECT id, src_id, title, body, link, timestamp, raw, full_text, author, categories, guid, content, enclosures, image FROM article WHERE id = ? LIMIT 1", old.Id().Value())
+	if err != nil {
+		return nil, err
+	}
+	if len(as) < 1 {
+		return nil, fmt.Errorf("cannot find the article.")
+	}
+	return as[0].WithRevision(revision + 1), errors.ERR_UPDATED_ARTICLE
+}
+
+func (self *Session) GetArticleRevisions(id *model.Id) ([]*model.ArticleRevision, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	artcl, err := self.getArticle(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := self.db.QueryContext(self.msn.AsContext(),
+		"SELECT revision, title, body, content, timestamp FROM article_revision WHERE article_id = ? ORDER BY revision ASC", id.Value())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// a revision has the time when it is replaced, so it is the time when the next version is recorded.
+	revs := []*model.ArticleRevision{}
+	recorded := artcl.Unixtime()
+	for rows.Next() {
+		rev := &model.ArticleRevision{}
+		var replaced time.Time
+		if err := rows.Scan(&rev.Revision, &rev.Title, &rev.Body, &rev.Content, &replaced); err != nil {
+			return nil, err
+		}
+		rev.Unixtime = recorded
+		recorded = replaced.Unix()
+
+		revs = append(revs, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return append(revs, &model.ArticleRevision{
+		Revision: len(revs),
+		Title: artcl.Title(),
+		Body: artcl.Body(),
+		Content: artcl.Meta().Content,
+		Unixtime: recorded,
+	}), nil
+}
+
+func (self *Session) UpdateArticleFullText(id *model.Id, text string) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	_, err := self.db.ExecContext(self.msn.AsContext(),
+		"UPDATE article SET full_text = ? WHERE id = ?", text, id.Value())
+	return err
+}
+
+func (self *Session) LookupArticles(t_kw string, b_kw string, a_kw string, c_kw string, src_ids []*model.Id, start int64, end int64, limit int64) ([]*model.Article, error) {
 	self.mtx.RLock()
 	defer self.mtx.RUnlock()
 
-	q := "SELECT id, src_id, title, body, link, timestamp, raw FROM article WHERE disable <> 1"
+	q := "SELECT id, src_id, title, body, link, timestamp, raw, full_text, author, categories, guid, content, enclosures, image FROM article WHERE disable <> 1"
 	args := make([]any, 0)
 	if t_kw != "" && b_kw != "" {
-		q += " AND (title LIKE CONCAT('%', ?, '%') OR body LIKE CONCAT('%', ?, '%'))"
+		q += " AND (title LIKE CONCAT('%', ?, '%') OR body LIKE CONCAT('%', ?, '%') OR full_text LIKE CONCAT('%', ?, '%') OR content LIKE CONCAT('%', ?, '%'))"
 		args = append(args, t_kw)
 		args = append(args, b_kw)
+		args = append(args, b_kw)
+		args = append(args, b_kw)
 	} else {
 		if t_kw != "" {
 			q += " AND title LIKE CONCAT('%', ?, '%')"
 			args = append(args, t_kw)
 		}
 		if b_kw != "" {
-			q += " AND body LIKE CONCAT('%', ?, '%')"
+			q += " AND (body LIKE CONCAT('%', ?, '%') OR full_text LIKE CONCAT('%', ?, '%') OR content LIKE CONCAT('%', ?, '%'))"
+			args = append(args, b_kw)
+			args = append(args, b_kw)
 			args = append(args, b_kw)
 		}
 	}
+	if a_kw != "" {
+		q += " AND author LIKE CONCAT('%', ?, '%')"
+		args = append(args, a_kw)
+	}
+	if c_kw != "" {
+		q += " AND categories LIKE CONCAT('%', ?, '%')"
+		args = append(args, c_kw)
+	}
 
 	if start > 0 {
 		q += " AND timestamp >= FROM_UNIXTIME(?)"
@@ -568,7 +1154,7 @@ func (self *Session) GetFeed(src_id *model.Id, limit int64) ([]*model.Article, e
 	}
 
 	var q string = `
-SELECT a.id, f.src_id, a.title, a.body, a.link, a.timestamp, a.raw
+SELECT a.id, f.src_id, a.title, a.body, a.link, a.timestamp, a.raw, a.full_text, a.author, a.categories, a.guid, a.content, a.enclosures, a.image
 FROM article a
 JOIN feed f ON a.id = f.article_id
 WHERE f.src_id = ? AND a.disable <> 1 AND f.disable <> 1
@@ -619,10 +1205,23 @@ func (self *Session) query4article(q string, args ...any) ([]*model.Article, err
 		var link string
 		var t_stamp time.Time
 		var raw string
+		var full_text string
+		var categories string
+		var enclosures string
+		meta := &model.ArticleMeta{}
 
-		if err = rows.Scan(&id_base, &src_id_base, &title, &body, &link, &t_stamp, &raw); err != nil {
+		if err = rows.Scan(&id_base, &src_id_base, &title, &body, &link, &t_stamp, &raw, &full_text,
+				&meta.Author, &categories, &meta.Guid, &meta.Content, &enclosures, &meta.Image); err != nil {
+			return nil, err
+		}
+		if err := unmarshalList(categories, &meta.Categories); err != nil {
 			return nil, err
 		}
+		var ex_encs []*external.Enclosure
+		if err := unmarshalList(enclosures, &ex_encs); err != nil {
+			return nil, err
+		}
+		meta.Enclosures = model.ImportExternalEnclosures(ex_encs)
 		id := model.NewId(id_base)
 		src_id := model.NewId(src_id_base)
 
@@ -640,7 +1239,8 @@ func (self *Session) query4article(q string, args ...any) ([]*model.Article, err
 		if err = rows.Err(); err != nil {
 			return nil, err
 		}
-		articles = append(articles, model.NewArticle(id, src, title, body, link, t_stamp.Unix(), raw))
+		artcl := model.NewArticle(id, src, title, body, link, t_stamp.Unix(), raw)
+		articles = append(articles, artcl.WithMeta(meta).WithFullText(full_text))
 	}
 
 	if err := rows.Err(); err != nil {
@@ -649,6 +1249,25 @@ func (self *Session) query4article(q string, args ...any) ([]*model.Article, err
 	return articles, nil
 }
 
+// marshalList stores a list as a json array. an empty list is an empty string.
+func marshalList[T any](l []T) (string, error) {
+	if len(l) < 1 {
+		return "", nil
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func unmarshalList[T any](s string, l *[]T) error {
+	if s == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(s), l)
+}
+
 func (self *Session) AddAction(name string, cmd string) (*filter.Action, error) {
 	self.mtx.Lock()
 	defer self.mtx.Unlock()
@@ -742,15 +1361,15 @@ func (self *Session) DeleteAction(id *model.Id) error {
 	return err
 }
 
-func (self *Session) AddFilter(title string, regex_title bool, body string, regex_body bool, action_id *model.Id) (*filter.Filter, error) {
+func (self *Session) AddFilter(title string, regex_title bool, body string, regex_body bool, on_update bool, action_id *model.Id) (*filter.Filter, error) {
 	self.mtx.Lock()
 	defer self.mtx.Unlock()
 
 	id := model.NewId(nil)
 
 	_, err := self.db.ExecContext(self.msn.AsContext(),
-		"INSERT INTO filter (id, val_title, is_regex_title, val_body, is_regex_body, action_id) VALUES (?, ?, ?, ?, ?, ?)",
-			id.Value(), title, regex_title, body, regex_body, action_id.Value())
+		"INSERT INTO filter (id, val_title, is_regex_title, val_body, is_regex_body, on_update, action_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			id.Value(), title, regex_title, body, regex_body, on_update, action_id.Value())
 	if err != nil {
 		return nil, err
 	}
@@ -774,6 +1393,22 @@ func (self *Session) UpdateFilterAction(id *model.Id, action_id *model.Id) (*fil
 	return self.getFilter(id)
 }
 
+func (self *Session) UpdateFilterOnUpdate(id *model.Id, on_update bool) (*filter.Filter, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	if _, err := self.getFilter(id); err != nil {
+		return nil, err
+	}
+
+	_, err := self.db.ExecContext(self.msn.AsContext(),
+		"UPDATE filter SET on_update = ? WHERE id = ?", on_update, id.Value())
+	if err != nil {
+		return nil, err
+	}
+	return self.getFilter(id)
+}
+
 func (self *Session) GetFilter(id *model.Id) (*filter.Filter, error) {
 	self.mtx.RLock()
 	defer self.mtx.RUnlock()
@@ -782,7 +1417,7 @@ func (self *Session) GetFilter(id *model.Id) (*filter.Filter, error) {
 }
 
 func (self *Session) getFilter(id *model.Id) (*filter.Filter, error) {
-	rows, err := self.db.Query("SELECT * FROM filter WHERE id = ?  ORDER BY id ASC LIMIT 1", id.Value())
+	rows, err := self.db.Query("SELECT " + FILTER_COLUMNS + " FROM filter WHERE id = ?  ORDER BY id ASC LIMIT 1", id.Value())
 	if err != nil {
 		return nil, err
 	}
@@ -793,9 +1428,10 @@ func (self *Session) getFilter(id *model.Id) (*filter.Filter, error) {
 	var is_regex_title bool
 	var val_body       string
 	var is_regex_body  bool
+	var on_update      bool
 	var action_id_base []byte
 	for rows.Next() {
-		err := rows.Scan(&id_base, &val_title, &is_regex_title, &val_body, &is_regex_body, &action_id_base)
+		err := rows.Scan(&id_base, &val_title, &is_regex_title, &val_body, &is_regex_body, &on_update, &action_id_base)
 		if err != nil {
 			return nil, err
 		}
@@ -807,7 +1443,7 @@ func (self *Session) getFilter(id *model.Id) (*filter.Filter, error) {
 			return nil, err
 		}
 
-		f := filter.NewFilter(id, val_title, is_regex_title, val_body, is_regex_body, action)
+		f := filter.NewFilter(id, val_title, is_regex_title, val_body, is_regex_body, on_update, action)
 
 		if err = rows.Err(); err != nil {
 			return nil, err
@@ -821,7 +1457,7 @@ func (self *Session) GetFilters() ([]*filter.Filter, error) {
 	self.mtx.RLock()
 	defer self.mtx.RUnlock()
 
-	rows, err := self.db.Query("SELECT * FROM filter ORDER BY val_title ASC")
+	rows, err := self.db.Query("SELECT " + FILTER_COLUMNS + " FROM filter ORDER BY val_title ASC")
 	if err != nil {
 		return nil, err
 	}
@@ -835,9 +1471,10 @@ func (self *Session) GetFilters() ([]*filter.Filter, error) {
 		var is_regex_title bool
 		var val_body       string
 		var is_regex_body  bool
+		var on_update      bool
 		var action_id_base []byte
 
-		err := rows.Scan(&id_base, &val_title, &is_regex_title, &val_body, &is_regex_body, &action_id_base)
+		err := rows.Scan(&id_base, &val_title, &is_regex_title, &val_body, &is_regex_body, &on_update, &action_id_base)
 		if err != nil {
 			return nil, err
 		}
@@ -855,7 +1492,7 @@ func (self *Session) GetFilters() ([]*filter.Filter, error) {
 			action_cache[action_id.String()] = action
 		}
 
-		f_s = append(f_s, filter.NewFilter(id, val_title, is_regex_title, val_body, is_regex_body, action))
+		f_s = append(f_s, filter.NewFilter(id, val_title, is_regex_title, val_body, is_regex_body, on_update, action))
 	}
 
 	if err := rows.Err(); err != nil {
@@ -3,24 +3,73 @@ package mysql
 func make_table_dict() ([]string, map[string]string) {
 	d := make(map[string]string)
 	order := []string{
-		"source_type", "source",
+		"source_type", "source", "source_state", "source_option", "source_fetch_log", "websub_subscription",
 		"action", "filter", "src_filter_map",
-		"article", "feed",
+		"article", "article_revision", "feed",
 	}
 
 	d["source_type"] = TABLE_SOURCE_TYPE
 	d["source"] = TABLE_SOURCE
+	d["source_state"] = TABLE_SOURCE_STATE
+	d["source_option"] = TABLE_SOURCE_OPTION
+	d["source_fetch_log"] = TABLE_SOURCE_FETCH_LOG
+	d["websub_subscription"] = TABLE_WEBSUB_SUBSCRIPTION
 
 	d["filter"] = TABLE_FILTER
 	d["action"] = TABLE_ACTION
 	d["src_filter_map"] = TABLE_SOURCE_FILTER_MAP
 
 	d["article"] = TABLE_ARTICLE
+	d["article_revision"] = TABLE_ARTICLE_REVISION
 	d["feed"] = TABLE_FEED
 
 	return order, d
 }
 
+type column struct {
+	name string
+	def  string
+}
+
+func make_column_dict() ([]string, map[string][]*column) {
+	d := make(map[string][]*column)
+	order := []string{
+		"source", "filter", "article",
+	}
+
+	d["source"] = []*column{
+		&column{name: "interval_sec", def: "INT NOT NULL DEFAULT 0"},
+		&column{name: "group_name", def: "VARCHAR(255) NOT NULL DEFAULT ''"},
+	}
+	d["filter"] = []*column{
+		&column{name: "on_update", def: "BOOLEAN NOT NULL DEFAULT 0"},
+	}
+	d["article"] = []*column{
+		&column{name: "full_text", def: "LONGTEXT NOT NULL DEFAULT ('')"},
+		&column{name: "author", def: "TEXT NOT NULL DEFAULT ('')"},
+		&column{name: "categories", def: "TEXT NOT NULL DEFAULT ('')"},
+		&column{name: "guid", def: "TEXT NOT NULL DEFAULT ('')"},
+		&column{name: "content", def: "LONGTEXT NOT NULL DEFAULT ('')"},
+		&column{name: "enclosures", def: "TEXT NOT NULL DEFAULT ('')"},
+		&column{name: "image", def: "TEXT NOT NULL DEFAULT ('')"},
+		&column{name: "dedup_key", def: "CHAR(64) NOT NULL DEFAULT ''"},
+	}
+
+	return order, d
+}
+
+type index struct {
+	table   string
+	name    string
+	columns string
+}
+
+func make_index_list() []*index {
+	return []*index{
+		&index{table: "article", name: "idx_article_dedup_key", columns: "src_id, dedup_key"},
+	}
+}
+
 const TABLE_ACTION string = `
 id BINARY(16) NOT NULL,
 name VARCHAR(255) UNIQUE NOT NULL,
@@ -68,6 +117,68 @@ PRIMARY KEY (id),
 FOREIGN KEY (type) REFERENCES source_type(id)
 `
 
+const TABLE_SOURCE_STATE string = `
+src_id BINARY(16) NOT NULL,
+etag VARCHAR(1024) NOT NULL DEFAULT '',
+last_modified VARCHAR(255) NOT NULL DEFAULT '',
+ttl_sec INT NOT NULL DEFAULT 0,
+skip_hours INT UNSIGNED NOT NULL DEFAULT 0,
+skip_days TINYINT UNSIGNED NOT NULL DEFAULT 0,
+retry_after BIGINT NOT NULL DEFAULT 0,
+last_update BIGINT NOT NULL DEFAULT 0,
+idle INT NOT NULL DEFAULT 0,
+next_fetch BIGINT NOT NULL DEFAULT 0,
+hub VARCHAR(1024) NOT NULL DEFAULT '',
+topic VARCHAR(1024) NOT NULL DEFAULT '',
+cursor_pos VARCHAR(1024) NOT NULL DEFAULT '',
+failures INT NOT NULL DEFAULT 0,
+permanent BOOLEAN NOT NULL DEFAULT 0,
+quarantine TEXT NOT NULL DEFAULT (''),
+quarantined BIGINT NOT NULL DEFAULT 0,
+PRIMARY KEY (src_id),
+FOREIGN KEY (src_id) REFERENCES source(id)
+`
+
+const TABLE_SOURCE_OPTION string = `
+src_id BINARY(16) NOT NULL,
+full_text BOOLEAN NOT NULL DEFAULT 0,
+dedup VARCHAR(32) NOT NULL DEFAULT '',
+http TEXT NOT NULL DEFAULT (''),
+hook TEXT NOT NULL DEFAULT (''),
+PRIMARY KEY (src_id),
+FOREIGN KEY (src_id) REFERENCES source(id)
+`
+
+// a collection of a source. the timestamp is when it is started.
+const TABLE_SOURCE_FETCH_LOG string = `
+id BINARY(16) NOT NULL,
+src_id BINARY(16) NOT NULL,
+timestamp TIMESTAMP NOT NULL,
+duration_ms BIGINT NOT NULL DEFAULT 0,
+http_status INT NOT NULL DEFAULT 0,
+bytes BIGINT NOT NULL DEFAULT 0,
+items INT NOT NULL DEFAULT 0,
+new_articles INT NOT NULL DEFAULT 0,
+success BOOLEAN NOT NULL DEFAULT 0,
+permanent BOOLEAN NOT NULL DEFAULT 0,
+log TEXT NOT NULL,
+PRIMARY KEY (id),
+INDEX idx_source_fetch_log_timestamp (src_id, timestamp),
+FOREIGN KEY (src_id) REFERENCES source(id)
+`
+
+const TABLE_WEBSUB_SUBSCRIPTION string = `
+src_id BINARY(16) NOT NULL,
+hub VARCHAR(1024) NOT NULL,
+topic VARCHAR(1024) NOT NULL,
+secret VARCHAR(255) NOT NULL,
+requested BIGINT NOT NULL DEFAULT 0,
+lease_end BIGINT NOT NULL DEFAULT 0,
+pending BOOLEAN NOT NULL DEFAULT 0,
+PRIMARY KEY (src_id),
+FOREIGN KEY (src_id) REFERENCES source(id)
+`
+
 const TABLE_ARTICLE string = `
 id BINARY(16) NOT NULL,
 src_id BINARY(16) NOT NULL,
@@ -82,6 +193,20 @@ FOREIGN KEY (src_id) REFERENCES source(id)
 `
 // 0 is false at boolean
 
+// a previous version of an article. the timestamp is when it is replaced by the next version.
+const TABLE_ARTICLE_REVISION string = `
+id BINARY(16) NOT NULL,
+article_id BINARY(16) NOT NULL,
+revision INT NOT NULL,
+title LONGTEXT NOT NULL,
+body LONGTEXT NOT NULL,
+content LONGTEXT NOT NULL,
+timestamp TIMESTAMP NOT NULL,
+PRIMARY KEY (id),
+UNIQUE (article_id, revision),
+FOREIGN KEY (article_id) REFERENCES article(id)
+`
+
 const TABLE_FEED string = `
 src_id BINARY(16) NOT NULL,
 article_id BINARY(16) NOT NULL,
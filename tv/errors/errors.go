@@ -6,4 +6,5 @@ import (
 
 var (
 	ERR_ALREADY_EXIST_ARTICLE = fmt.Errorf("the article is already exist.")
+	ERR_UPDATED_ARTICLE       = fmt.Errorf("the article is updated.")
 )
@@ -91,15 +91,26 @@ func (self *TimeVortex) deleteSourceType(id *model.Id) error {
 	return self.db.DeleteSourceType(id)
 }
 
-func (self *TimeVortex) AddSource(title string, src_type_id *model.Id, val string) (*model.Source, error) {
+func (self *TimeVortex) AddSource(title string, src_type_id *model.Id, val string, interval int, group string) (*model.Source, error) {
 	self.mtx.Lock()
 	defer self.mtx.Unlock()
 
-	return self.addSource(title, src_type_id, val)
+	return self.addSource(title, src_type_id, val, interval, group)
 }
 
-func (self *TimeVortex) addSource(title string, src_type_id *model.Id, val string) (*model.Source, error) {
-	return self.db.AddSource(title, src_type_id, val)
+func (self *TimeVortex) addSource(title string, src_type_id *model.Id, val string, interval int, group string) (*model.Source, error) {
+	return self.db.AddSource(title, src_type_id, val, interval, group)
+}
+
+func (self *TimeVortex) UpdateSource(id *model.Id, title string, val string, interval int, group string) (*model.Source, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.updateSource(id, title, val, interval, group)
+}
+
+func (self *TimeVortex) updateSource(id *model.Id, title string, val string, interval int, group string) (*model.Source, error) {
+	return self.db.UpdateSource(id, title, val, interval, group)
 }
 
 func (self *TimeVortex) GetSources() ([]*model.Source, error) {
@@ -168,11 +179,109 @@ func (self *TimeVortex) resumeSource(id *model.Id) error {
 	return self.db.ResumeSource(id)
 }
 
-func (self *TimeVortex) AddArticle(title string, body string, link string, utime int64, raw string, src_id *model.Id) (*model.Article, error){
+func (self *TimeVortex) GetSourceState(src_id *model.Id) (*model.SourceState, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	return self.db.GetSourceState(src_id)
+}
+
+func (self *TimeVortex) UpdateSourceState(src_id *model.Id, st *model.SourceState) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.db.UpdateSourceState(src_id, st)
+}
+
+func (self *TimeVortex) GetSourceOption(src_id *model.Id) (*model.SourceOption, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	return self.db.GetSourceOption(src_id)
+}
+
+func (self *TimeVortex) UpdateSourceOption(src_id *model.Id, opt *model.SourceOption) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.db.UpdateSourceOption(src_id, opt)
+}
+
+func (self *TimeVortex) AddFetchLog(src_id *model.Id, l *model.FetchLog) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.db.AddFetchLog(src_id, l)
+}
+
+func (self *TimeVortex) GetFetchLogs(src_id *model.Id, since int64, limit int64) ([]*model.FetchLog, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	return self.db.GetFetchLogs(src_id, since, limit)
+}
+
+func (self *TimeVortex) GetLatestFetchLogs(limit int64) (map[string][]*model.FetchLog, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	return self.db.GetLatestFetchLogs(limit)
+}
+
+func (self *TimeVortex) DeleteFetchLogs(before int64) (int64, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.db.DeleteFetchLogs(before)
+}
+
+func (self *TimeVortex) GetSubscriptions() ([]*model.Subscription, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	return self.db.GetSubscriptions()
+}
+
+func (self *TimeVortex) GetSubscription(src_id *model.Id) (*model.Subscription, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	return self.db.GetSubscription(src_id)
+}
+
+func (self *TimeVortex) UpdateSubscription(sub *model.Subscription) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.db.UpdateSubscription(sub)
+}
+
+func (self *TimeVortex) DeleteSubscription(src_id *model.Id) error {
 	self.mtx.Lock()
 	defer self.mtx.Unlock()
 
-	return self.db.AddArticle(title, body, link, utime, raw, src_id)
+	return self.db.DeleteSubscription(src_id)
+}
+
+func (self *TimeVortex) AddArticle(title string, body string, link string, utime int64, raw string, meta *model.ArticleMeta, src_id *model.Id) (*model.Article, error){
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.db.AddArticle(title, body, link, utime, raw, meta, src_id)
+}
+
+func (self *TimeVortex) GetArticleRevisions(id *model.Id) ([]*model.ArticleRevision, error) {
+	self.mtx.RLock()
+	defer self.mtx.RUnlock()
+
+	return self.db.GetArticleRevisions(id)
+}
+
+func (self *TimeVortex) UpdateArticleFullText(id *model.Id, text string) error {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.db.UpdateArticleFullText(id, text)
 }
 
 func (self *TimeVortex) RemoveArticle(id *model.Id) error {
@@ -182,11 +291,11 @@ func (self *TimeVortex) RemoveArticle(id *model.Id) error {
 	return self.db.RemoveArticle(id)
 }
 
-func (self *TimeVortex) LookupArticles(t_kw string, b_kw string, src_ids []*model.Id, start int64, end int64, limit int64) ([]*model.Article, error) {
+func (self *TimeVortex) LookupArticles(t_kw string, b_kw string, a_kw string, c_kw string, src_ids []*model.Id, start int64, end int64, limit int64) ([]*model.Article, error) {
 	self.mtx.RLock()
 	defer self.mtx.RUnlock()
 
-	return self.db.LookupArticles(t_kw, b_kw, src_ids, start, end, limit)
+	return self.db.LookupArticles(t_kw, b_kw, a_kw, c_kw, src_ids, start, end, limit)
 }
 
 func (self *TimeVortex) GetFeed(src_id *model.Id, limit int64) ([]*model.Article, error) {
@@ -238,11 +347,11 @@ func (self *TimeVortex) DeleteAction(id *model.Id) error {
 	return self.db.DeleteAction(id)
 }
 
-func (self *TimeVortex) AddFilter(title string, regex_title bool, body string, regex_body bool, action_id *model.Id) (*filter.Filter, error) {
+func (self *TimeVortex) AddFilter(title string, regex_title bool, body string, regex_body bool, on_update bool, action_id *model.Id) (*filter.Filter, error) {
 	self.mtx.Lock()
 	defer self.mtx.Unlock()
 
-	return self.db.AddFilter(title, regex_title, body, regex_body, action_id)
+	return self.db.AddFilter(title, regex_title, body, regex_body, on_update, action_id)
 }
 
 func (self *TimeVortex) UpdateFilterAction(id *model.Id, action_id *model.Id) (*filter.Filter, error) {
@@ -252,6 +361,13 @@ func (self *TimeVortex) UpdateFilterAction(id *model.Id, action_id *model.Id) (*
 	return self.db.UpdateFilterAction(id, action_id)
 }
 
+func (self *TimeVortex) UpdateFilterOnUpdate(id *model.Id, on_update bool) (*filter.Filter, error) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	return self.db.UpdateFilterOnUpdate(id, on_update)
+}
+
 func (self *TimeVortex) GetFilter(id *model.Id) (*filter.Filter, error) {
 	self.mtx.RLock()
 	defer self.mtx.RUnlock()
@@ -0,0 +1,318 @@
+package gwyneth
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/l4go/task"
+)
+
+import (
+	"github.com/hinoshiba/gwyneth/slog"
+	"github.com/hinoshiba/gwyneth/model"
+	"github.com/hinoshiba/gwyneth/collector"
+	"github.com/hinoshiba/gwyneth/collector/websub"
+)
+
+const (
+	WEBSUB_RENEW_MARGIN   = 60 * 60 * 24 // renews a lease which ends within this seconds.
+	WEBSUB_RETRY_INTERVAL = 60 * 60      // retries a request which is not verified after this seconds.
+	WEBSUB_CHECK_INTERVAL = 10 * time.Minute
+)
+
+func (self *Gwyneth) run_websub(msn *task.Mission) {
+	defer msn.Done()
+
+	if !self.cfg.Collector.WebSub.IsEnabled() {
+		return
+	}
+	slog.Debug("start websub subscriber")
+
+	subs, err := self.tv.GetSubscriptions()
+	if err != nil {
+		slog.Warn("failed: cannot get websub subscriptions: %s", err)
+	}
+	for _, sub := range subs {
+		if sub.IsActive(time.Now()) {
+			self.sched.SetPush(sub.SrcId(), time.Unix(sub.LeaseEnd(), 0))
+		}
+	}
+
+	ticker := time.NewTicker(WEBSUB_CHECK_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <- msn.RecvCancel():
+			return
+		case now := <- ticker.C:
+			subs, err := self.tv.GetSubscriptions()
+			if err != nil {
+				slog.Warn("failed: cannot get websub subscriptions: %s", err)
+				continue
+			}
+			for _, sub := range subs {
+				if !needRenew(sub, now) {
+					continue
+				}
+				if _, ok := self.sched.Next(sub.SrcId()); !ok {
+					continue
+				}
+				if err := self.requestSubscription(msn.New(), sub.SrcId(), sub.Hub(), sub.Topic()); err != nil {
+					slog.Warn("failed: cannot renew the websub subscription of '%s': %s", sub.SrcId(), err)
+				}
+			}
+		}
+	}
+}
+
+func needRenew(sub *model.Subscription, now time.Time) bool {
+	if now.Unix() - sub.RequestedAt() < WEBSUB_RETRY_INTERVAL {
+		return false
+	}
+	if !sub.IsActive(now) {
+		return true
+	}
+	return sub.LeaseEnd() - now.Unix() < WEBSUB_RENEW_MARGIN
+}
+
+func (self *Gwyneth) websubCallback(src_id *model.Id) string {
+	cb := self.cfg.Collector.WebSub.Callback
+	if !strings.HasSuffix(cb, "/") {
+		cb += "/"
+	}
+	return cb + "websub/" + src_id.String()
+}
+
+func (self *Gwyneth) subscribe(msn *task.Mission, src *model.Source, st *model.SourceState) error {
+	defer msn.Done()
+
+	if !self.cfg.Collector.WebSub.IsEnabled() {
+		return nil
+	}
+	if st.Hub == "" || st.Topic == "" {
+		return nil
+	}
+
+	sub, err := self.tv.GetSubscription(src.Id())
+	if err == nil && sub.Hub() == st.Hub && sub.Topic() == st.Topic {
+		if !needRenew(sub, time.Now()) {
+			return nil
+		}
+	}
+	return self.requestSubscription(msn.New(), src.Id(), st.Hub, st.Topic)
+}
+
+func (self *Gwyneth) requestSubscription(msn *task.Mission, src_id *model.Id, hub string, topic string) error {
+	defer msn.Done()
+
+	var secret string
+	var lease_end int64
+	if sub, err := self.tv.GetSubscription(src_id); err == nil {
+		if sub.Hub() == hub && sub.Topic() == topic {
+			secret = sub.Secret()
+			lease_end = sub.LeaseEnd()
+		} else {
+			// the feed moved to another hub or topic, so the old one is not needed.
+			self.unsubs.Add(src_id, sub.Topic())
+			if err := websub.Unsubscribe(msn.New(), sub.Hub(), sub.Topic(), self.websubCallback(src_id)); err != nil {
+				slog.Warn("failed: cannot unsubscribe '%s' from '%s': %s", sub.Topic(), sub.Hub(), err)
+			}
+		}
+	}
+	if secret == "" {
+		s, err := websub.NewSecret()
+		if err != nil {
+			return err
+		}
+		secret = s
+	}
+
+	sub := model.NewSubscription(src_id, hub, topic, secret, time.Now().Unix(), lease_end, true)
+	if err := self.tv.UpdateSubscription(sub); err != nil {
+		return err
+	}
+	return websub.Subscribe(msn.New(), hub, topic, self.websubCallback(src_id), secret, self.cfg.Collector.WebSub.Lease)
+}
+
+func (self *Gwyneth) unsubscribe(msn *task.Mission, src_id *model.Id) {
+	defer msn.Done()
+
+	sub, err := self.tv.GetSubscription(src_id)
+	if err != nil {
+		return
+	}
+	if err := self.tv.DeleteSubscription(src_id); err != nil {
+		slog.Warn("failed: cannot delete the websub subscription of '%s': %s", src_id, err)
+		return
+	}
+	self.sched.SetPush(src_id, time.Time{})
+
+	if !self.cfg.Collector.WebSub.IsEnabled() {
+		return
+	}
+	self.unsubs.Add(src_id, sub.Topic())
+	if err := websub.Unsubscribe(msn.New(), sub.Hub(), sub.Topic(), self.websubCallback(src_id)); err != nil {
+		slog.Warn("failed: cannot unsubscribe '%s' from '%s': %s", sub.Topic(), sub.Hub(), err)
+	}
+}
+
+// VerifyWebSub answers the verification of the hub. Only the request which gwyneth sent and is not verified yet
+// is accepted, so a third party cannot change the subscription.
+func (self *Gwyneth) VerifyWebSub(src_id *model.Id, mode string, topic string, challenge string, lease int) (string, error) {
+	if mode == websub.MODE_UNSUBSCRIBE {
+		if !self.unsubs.Take(src_id, topic) {
+			return "", fmt.Errorf("unsubscription is not requested: '%s'", topic)
+		}
+		return challenge, nil
+	}
+
+	sub, err := self.tv.GetSubscription(src_id)
+	if err != nil {
+		return "", err
+	}
+	if sub.Topic() != topic {
+		return "", fmt.Errorf("unknown topic: '%s'", topic)
+	}
+
+	switch mode {
+	case websub.MODE_SUBSCRIBE:
+		if !sub.IsPending() {
+			return "", fmt.Errorf("subscription is not requested: '%s'", topic)
+		}
+		// the lease is not longer than the requested one.
+		if lease < 1 || lease > self.cfg.Collector.WebSub.Lease {
+			lease = self.cfg.Collector.WebSub.Lease
+		}
+		lease_end := time.Now().Add(time.Duration(lease) * time.Second)
+
+		new_sub := model.NewSubscription(src_id, sub.Hub(), sub.Topic(), sub.Secret(), sub.RequestedAt(), lease_end.Unix(), false)
+		if err := self.tv.UpdateSubscription(new_sub); err != nil {
+			return "", err
+		}
+		self.sched.SetPush(src_id, lease_end)
+
+		slog.Info("websub: subscribed '%s' until %s", topic, lease_end)
+		return challenge, nil
+	case websub.MODE_DENIED:
+		// the hub can deny an active subscription too, e.g. when the topic is removed.
+		if err := self.tv.DeleteSubscription(src_id); err != nil {
+			return "", err
+		}
+		self.sched.SetPush(src_id, time.Time{})
+
+		slog.Warn("websub: the subscription of '%s' is denied by the hub", topic)
+		return "", nil
+	}
+	return "", fmt.Errorf("unsupported mode: '%s'", mode)
+}
+
+func (self *Gwyneth) ReceiveWebSub(src_id *model.Id, sig string, body []byte) error {
+	sub, err := self.tv.GetSubscription(src_id)
+	if err != nil {
+		return err
+	}
+
+	logger := self.lm.GetCollectorsLogger()
+	if !websub.VerifySignature(sub.Secret(), sig, body) {
+		// the hub has to get a success response even if the signature is invalid.
+		logger.Warn("websub: ignored the content which has an invalid signature: '%s'", sub.Topic())
+		return nil
+	}
+
+	src, err := self.tv.GetSource(src_id)
+	if err != nil {
+		return err
+	}
+	if src.IsPause() {
+		return nil
+	}
+
+	clctr, err := collector.Lookup(src.Type())
+	if err != nil {
+		return err
+	}
+	r, ok := clctr.(collector.Receiver)
+	if !ok {
+		return fmt.Errorf("the collector of '%s' cannot receive a content", src.Type().Name())
+	}
+
+	started := time.Now()
+	job := &collector.Job{
+		Logger: logger,
+		Src: src,
+	}
+	new_artcls, rec_err := self.runJob(self.msn.New(), job, false, func() {
+		err = r.Receive(self.msn.New(), job, bytes.NewReader(body))
+	})
+	if err == nil {
+		err = rec_err
+	}
+	if err != nil {
+		logger.Warn("websub: cannot read the content of '%s': %s", src.Title(), err)
+		self.addFetchLog(src.Id(), started, collector.MakeFailedStatus("%s", err), job, new_artcls)
+		return nil
+	}
+	self.addFetchLog(src.Id(), started, collector.MakeSucceededStatus("Succeeded: pushed"), job, new_artcls)
+	return nil
+}
+
+// unsubscriptions is the unsubscriptions which are requested and not verified by the hubs yet.
+type unsubscriptions struct {
+	topics map[string]map[string]time.Time
+	mtx    *sync.Mutex
+}
+
+func newUnsubscriptions() *unsubscriptions {
+	return &unsubscriptions{
+		topics: make(map[string]map[string]time.Time),
+		mtx: new(sync.Mutex),
+	}
+}
+
+func (self *unsubscriptions) Add(src_id *model.Id, topic string) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	now := time.Now()
+	for id, topics := range self.topics {
+		for t, requested := range topics {
+			if now.Unix() - requested.Unix() > WEBSUB_RETRY_INTERVAL {
+				delete(topics, t)
+			}
+		}
+		if len(topics) < 1 {
+			delete(self.topics, id)
+		}
+	}
+
+	topics, ok := self.topics[src_id.String()]
+	if !ok {
+		topics = make(map[string]time.Time)
+		self.topics[src_id.String()] = topics
+	}
+	topics[topic] = now
+}
+
+// Take returns whether the unsubscription is requested, and forgets it.
+func (self *unsubscriptions) Take(src_id *model.Id, topic string) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+
+	topics, ok := self.topics[src_id.String()]
+	if !ok {
+		return false
+	}
+	if _, ok := topics[topic]; !ok {
+		return false
+	}
+	delete(topics, topic)
+	if len(topics) < 1 {
+		delete(self.topics, src_id.String())
+	}
+	return true
+}